@@ -1,10 +1,11 @@
 package add
 
 import (
-	"log"
-
 	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/complete"
 	hist "github.com/shohi/cube/pkg/history"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/log"
 	"github.com/spf13/cobra"
 )
 
@@ -16,7 +17,7 @@ func New() *cobra.Command {
 		Short: "add remote cluster to kube config",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := hist.Write(); err != nil {
-				log.Printf("failed to write history, err: %v\n", err)
+				log.Warn("failed to write history", "err", err)
 			}
 			return action.Add(conf)
 		},
@@ -35,13 +36,23 @@ func setupFlags(cmd *cobra.Command, conf *action.AddConfig) {
 	flagSet.StringVar(&conf.RemoteUser, "remote-user", "core", "remote user")
 	flagSet.StringVar(&conf.RemoteIP, "remote-ip", "", "remote master private ip")
 
-	flagSet.IntVar(&conf.LocalPort, "local-port", 0, "local forwarding port")
+	flagSet.IntVar(&conf.LocalPort, "local-port", 0, "local forwarding port. If not set, the lowest available port in port-range will be used")
+	flagSet.StringVar(&conf.PortRange, "port-range", kube.DefaultPortRange().String(), "local forwarding port range. CUBE_PORT_RANGE env can be used as default")
+	flagSet.StringVarP(&conf.Labels, "labels", "l", "", "labels attached to cluster for selection, e.g. env=prod,region=eu")
 	flagSet.StringVar(&conf.SSHVia, "ssh-via", "", "ssh jump server, e.g. user@jump. If not set, SSH_VIA env will be used ")
 	flagSet.StringVar(&conf.NameSuffix, "name-suffix", "", "cluster name suffix")
 
 	flagSet.BoolVar(&conf.DryRun, "dry-run", false, "dry-run mode. validate config and then exit")
 	flagSet.BoolVar(&conf.Force, "force", false, "merge configuration forcedly. Only take effect when cluster name is unique")
+	flagSet.StringVar(&conf.OnConflict, "on-conflict", string(kube.ConflictFail), "how to resolve name conflicts, avaliable options: fail/replace/rename/skip")
 	flagSet.BoolVar(&conf.PrintSSHForwarding, "print-ssh-forwarding", false, "print ssh forwarding command and exit")
 
+	naming := kube.DefaultNaming()
+	flagSet.StringVar(&conf.Naming.Cluster, "cluster-name-template", naming.Cluster, "Go template for cluster name, fields: .Suffix/.Host/.Port/.RemoteUser/.Context")
+	flagSet.StringVar(&conf.Naming.User, "user-name-template", naming.User, "Go template for user name, fields: .Suffix/.Host/.Port/.RemoteUser/.Context")
+	flagSet.StringVar(&conf.Naming.Context, "context-name-template", naming.Context, "Go template for context name, fields: .Suffix/.Host/.Port/.RemoteUser/.Context")
+
 	cmd.MarkFlagRequired("remote-ip")
+	_ = cmd.RegisterFlagCompletionFunc("remote-ip", complete.RemoteIPs)
+	_ = cmd.RegisterFlagCompletionFunc("remote-user", complete.RemoteUsers)
 }
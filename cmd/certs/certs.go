@@ -0,0 +1,33 @@
+package certs
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/kube"
+)
+
+// New creates a new `certs` subcommand.
+func New() *cobra.Command {
+	var conf action.CertsConfig
+
+	c := &cobra.Command{
+		Use:   "certs",
+		Short: "list certificate expiry of managed clusters",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.Certs(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.CertsConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.StringVar(&conf.Window, "warn-within", kube.DefaultCertWindowString(), "flag certs expiring within the window, e.g. 30d or 72h. CUBE_CERT_WARN_WITHIN env can be used as default")
+	flagSet.BoolVar(&conf.ExpiringOnly, "expiring", false, "only show expired or expiring certs")
+}
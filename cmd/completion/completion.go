@@ -0,0 +1,41 @@
+package completion
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// New creates a new `completion` subcommand.
+func New() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "completion bash|zsh|fish|powershell",
+		Short: "generate shell completion script",
+		Example: `  source <(cube completion bash)
+  cube completion zsh > "${fpath[1]}/_cube"
+  cube completion fish | source
+  cube completion powershell | Out-String | Invoke-Expression`,
+		Args:                  cobra.ExactValidArgs(1),
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletion(os.Stdout)
+			case "zsh":
+				return root.GenZshCompletion(os.Stdout)
+			case "fish":
+				return root.GenFishCompletion(os.Stdout, true)
+			case "powershell":
+				return root.GenPowerShellCompletion(os.Stdout)
+			default:
+				return fmt.Errorf("completion: unsupported shell - %v", args[0])
+			}
+		},
+	}
+
+	return c
+}
@@ -1,12 +1,13 @@
 package del
 
 import (
-	"log"
-
 	"github.com/spf13/cobra"
 
 	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/complete"
 	hist "github.com/shohi/cube/pkg/history"
+	"github.com/shohi/cube/pkg/log"
+	"github.com/shohi/cube/pkg/matcher"
 )
 
 func New() *cobra.Command {
@@ -18,7 +19,7 @@ func New() *cobra.Command {
 		Short:   "delete kubectl config for specified cluster",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := hist.Write(); err != nil {
-				log.Printf("failed to write history, err: %v\n", err)
+				log.Warn("failed to write history", "err", err)
 			}
 			return action.Del(conf)
 		},
@@ -35,8 +36,12 @@ func setupFlags(cmd *cobra.Command, conf *action.DelConfig) {
 	flagSet := cmd.Flags()
 
 	flagSet.StringVar(&conf.Name, "name", "", "cluster name to delete")
+	flagSet.StringVar(&conf.Match, "match", string(matcher.Exact), "how name is matched, avaliable options: exact/prefix/glob/regex")
+	flagSet.StringVarP(&conf.Selector, "selector", "l", "", "label selector, e.g. env=prod,region!=us")
 	flagSet.BoolVar(&conf.DryRun, "dry-run", false, "dry-run mode. print modified config and exit")
+	flagSet.BoolVarP(&conf.Yes, "yes", "y", false, "skip confirmation")
+	flagSet.BoolVar(&conf.AllowCurrent, "allow-current", false, "allow to delete current-context")
 	flagSet.BoolVar(&conf.All, "all", false, "delete all matched cluster.")
 
-	cmd.MarkFlagRequired("name")
+	_ = cmd.RegisterFlagCompletionFunc("name", complete.ManagedClusters)
 }
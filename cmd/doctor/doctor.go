@@ -0,0 +1,31 @@
+package doctor
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+)
+
+// New creates a new `doctor` subcommand.
+func New() *cobra.Command {
+	var conf action.DoctorConfig
+
+	c := &cobra.Command{
+		Use:   "doctor",
+		Short: "diagnose local setup and cube state",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.Doctor(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.DoctorConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.BoolVar(&conf.Fix, "fix", false, "apply safe automatic repairs")
+}
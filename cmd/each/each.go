@@ -0,0 +1,56 @@
+package each
+
+import (
+	"errors"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/complete"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+var errInvalidArgs = errors.New("each: usage: cube each [--name <name>] [--selector <selector>] -- <command> [args...]")
+
+// New creates a new `each` subcommand.
+func New() *cobra.Command {
+	var conf action.EachConfig
+
+	c := &cobra.Command{
+		Use:   "each [flags] -- <command> [args...]",
+		Short: "run command against multiple clusters in parallel",
+		Example: `  cube each --selector env=prod -- kubectl get nodes
+  cube each --name 'qa-*' --match glob --output group -- kubectl version`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.ArgsLenAtDash() != 0 || len(args) == 0 {
+				return errInvalidArgs
+			}
+
+			conf.Args = args
+			return action.Each(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.EachConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.StringVar(&conf.Name, "name", "", "cluster name")
+	flagSet.StringVar(&conf.Match, "match", string(matcher.Regex), "how name is matched, avaliable options: exact/prefix/glob/regex")
+	flagSet.StringVarP(&conf.Selector, "selector", "l", "", "label selector, e.g. env=prod,region!=us")
+	flagSet.StringVar(&conf.Exclude, "exclude", matcher.DefaultExclude(), "regex of cluster names to exclude. CUBE_EXCLUDE env can be used as default")
+	flagSet.IntVarP(&conf.Parallel, "parallel", "p", 4, "max number of clusters to run command against at the same time")
+	flagSet.StringVarP(&conf.Output, "output", "o", action.EachOutputPrefix, "output mode, avaliable options: prefix/group")
+	flagSet.StringVar(&conf.SSHVia, "ssh-via", "", "ssh jump server, e.g. user@jump. If not set, SSH_VIA env will be used ")
+	flagSet.DurationVar(&conf.TunnelTimeout, "tunnel-timeout", 15*time.Second, "timeout waiting for ssh tunnel to be up")
+
+	_ = cmd.RegisterFlagCompletionFunc("name", complete.Clusters)
+}
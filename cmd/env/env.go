@@ -0,0 +1,31 @@
+package env
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+)
+
+// New creates a new `env` subcommand.
+func New() *cobra.Command {
+	var conf action.EnvConfig
+
+	c := &cobra.Command{
+		Use:   "env",
+		Short: "print KUBECONFIG covering all local kubeconfig files",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.Env(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.EnvConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.StringVar(&conf.Shell, "shell", "bash", "shell type, avaliable options: bash/zsh/fish")
+}
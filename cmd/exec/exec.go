@@ -0,0 +1,50 @@
+package exec
+
+import (
+	"errors"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/complete"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+var errInvalidArgs = errors.New("exec: usage: cube exec <name> -- <command> [args...]")
+
+// New creates a new `exec` subcommand.
+func New() *cobra.Command {
+	var conf action.ExecConfig
+
+	c := &cobra.Command{
+		Use:               "exec <name> -- <command> [args...]",
+		Short:             "run command against a cluster with a temporary kubeconfig",
+		ValidArgsFunction: complete.FirstArg(complete.Clusters),
+		Example: `  cube exec qa -- kubectl get pods
+  cube exec qa -- helm list -A`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.ArgsLenAtDash() != 1 || len(args) < 2 {
+				return errInvalidArgs
+			}
+
+			conf.Name, conf.Args = args[0], args[1:]
+			return action.Exec(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.ExecConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.StringVar(&conf.Match, "match", string(matcher.Exact), "how name is matched, avaliable options: exact/prefix/glob/regex")
+	flagSet.StringVar(&conf.SSHVia, "ssh-via", "", "ssh jump server, e.g. user@jump. If not set, SSH_VIA env will be used ")
+	flagSet.DurationVar(&conf.TunnelTimeout, "tunnel-timeout", 15*time.Second, "timeout waiting for ssh tunnel to be up")
+}
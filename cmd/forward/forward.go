@@ -2,6 +2,9 @@ package forward
 
 import (
 	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/complete"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/matcher"
 	"github.com/spf13/cobra"
 )
 
@@ -26,8 +29,13 @@ func setupFlags(cmd *cobra.Command, conf *action.ForwardConfig) {
 	flagSet := cmd.Flags()
 
 	flagSet.StringVar(&conf.Name, "name", "", "cluster name")
+	flagSet.StringVar(&conf.Match, "match", string(matcher.Regex), "how name is matched, avaliable options: exact/prefix/glob/regex")
+	flagSet.StringVar(&conf.Exclude, "exclude", matcher.DefaultExclude(), "regex of cluster names to exclude. CUBE_EXCLUDE env can be used as default")
+	flagSet.StringVarP(&conf.Selector, "selector", "l", "", "label selector, e.g. env=prod,region!=us")
 	flagSet.StringVar(&conf.Operation, "op", "print", "operation, avaliable options: print/run/stop")
+	flagSet.StringVar(&conf.CertWindow, "cert-warn-within", kube.DefaultCertWindowString(), "warn certs expiring within the window on run, e.g. 30d or 72h. CUBE_CERT_WARN_WITHIN env can be used as default")
 	flagSet.StringVar(&conf.SSHVia, "ssh-via", "", "ssh jump server, e.g. user@jump. If not set, SSH_VIA env will be used ")
 
-	cmd.MarkFlagRequired("name")
+	_ = cmd.RegisterFlagCompletionFunc("name", complete.ManagedClusters)
+	_ = cmd.RegisterFlagCompletionFunc("op", complete.Values("print", "run", "stop"))
 }
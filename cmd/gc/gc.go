@@ -0,0 +1,37 @@
+package gc
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+	hist "github.com/shohi/cube/pkg/history"
+	"github.com/shohi/cube/pkg/log"
+)
+
+// New creates a new `gc` subcommand.
+func New() *cobra.Command {
+	var conf action.GCConfig
+
+	c := &cobra.Command{
+		Use:   "gc",
+		Short: "remove dangling contexts, clusters and users",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := hist.Write(); err != nil {
+				log.Warn("failed to write history", "err", err)
+			}
+			return action.GC(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.GCConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.BoolVar(&conf.DryRun, "dry-run", false, "dry-run mode. print dangling entries and exit")
+	flagSet.BoolVarP(&conf.Yes, "yes", "y", false, "skip confirmation")
+}
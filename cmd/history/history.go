@@ -1,9 +1,8 @@
 package history
 
 import (
-	"log"
-
 	"github.com/shohi/cube/pkg/history"
+	"github.com/shohi/cube/pkg/log"
 	"github.com/spf13/cobra"
 )
 
@@ -13,7 +12,7 @@ func New() *cobra.Command {
 		Short: "show cube commands history",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := history.Read(); err != nil {
-				log.Printf("get cube history error, err: %v\n", err)
+				log.Error("failed to get cube history", "err", err)
 			}
 
 			return nil
@@ -0,0 +1,47 @@
+package hosts
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+	hist "github.com/shohi/cube/pkg/history"
+	"github.com/shohi/cube/pkg/log"
+)
+
+// New creates a new `hosts` subcommand.
+func New() *cobra.Command {
+	var conf action.HostsConfig
+
+	c := &cobra.Command{
+		Use:   "hosts check|install|remove",
+		Short: "check or manage the /etc/hosts entry mapping kubernetes to 127.0.0.1",
+		Example: `  cube hosts check
+  cube hosts install --sudo
+  cube hosts remove --sudo`,
+		Args:         cobra.ExactValidArgs(1),
+		ValidArgs:    []string{action.HostsCheck, action.HostsInstall, action.HostsRemove},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf.Operation = args[0]
+			if conf.Operation != action.HostsCheck && !conf.DryRun {
+				if err := hist.Write(); err != nil {
+					log.Warn("failed to write history", "err", err)
+				}
+			}
+			return action.Hosts(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.HostsConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.StringVar(&conf.Path, "hosts-file", "", "hosts file to update. If not set, CUBE_HOSTS_FILE env or /etc/hosts will be used")
+	flagSet.BoolVar(&conf.Sudo, "sudo", false, "write hosts file with sudo")
+	flagSet.BoolVar(&conf.DryRun, "dry-run", false, "dry-run mode. print updated hosts file and exit")
+}
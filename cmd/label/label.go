@@ -0,0 +1,51 @@
+package label
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/complete"
+	hist "github.com/shohi/cube/pkg/history"
+	"github.com/shohi/cube/pkg/log"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+// New creates a new `label` subcommand.
+func New() *cobra.Command {
+	var conf action.LabelConfig
+
+	c := &cobra.Command{
+		Use:   "label [key=value ...] [key- ...]",
+		Short: "update or show labels of clusters",
+		Example: `  cube label --name qa env=qa team=payments
+  cube label --selector env=qa team-
+  cube label --name qa`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				if err := hist.Write(); err != nil {
+					log.Warn("failed to write history", "err", err)
+				}
+			}
+
+			conf.Changes = args
+			return action.Label(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.LabelConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.StringVar(&conf.Name, "name", "", "cluster name")
+	flagSet.StringVar(&conf.Match, "match", string(matcher.Exact), "how name is matched, avaliable options: exact/prefix/glob/regex")
+	flagSet.StringVarP(&conf.Selector, "selector", "l", "", "label selector, e.g. env=prod,region!=us")
+	flagSet.BoolVar(&conf.Overwrite, "overwrite", false, "overwrite existing labels")
+	flagSet.BoolVar(&conf.DryRun, "dry-run", false, "dry-run mode. print updated labels and exit")
+
+	_ = cmd.RegisterFlagCompletionFunc("name", complete.ManagedClusters)
+}
@@ -1,86 +1,43 @@
 package list
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
-	"regexp"
-	"strings"
-
 	"github.com/spf13/cobra"
 
-	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/complete"
+	"github.com/shohi/cube/pkg/matcher"
 )
 
-// Options for list subcommand
-type Options struct {
-	Filter string // name filter
-}
-
 // New creates a new `list` subcommand.
-// Output format
-// cluster_name : `ssh-forwarding`
 func New() *cobra.Command {
-	var opts = &Options{}
+	var conf action.ListConfig
 
 	c := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "list clusters <name>",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			l, err := kube.ListAllClusters()
-			if err != nil {
-				log.Printf("list clusters error, err: %v\n", err)
-				return err
-			}
-			selected := filter(l, ByName(opts.Filter))
-
-			content, _ := json.MarshalIndent(selected, "", "  ")
-			fmt.Println(string(content))
-			return nil
+			return action.List(conf)
 		},
 	}
 
-	setupFlags(c, opts)
+	setupFlags(c, &conf)
 
 	return c
 }
 
-func filter(s kube.ClusterInfos, fn FilterFunc) kube.ClusterInfos {
-	result := make(kube.ClusterInfos, 0, len(s))
-
-	for _, c := range s {
-		if fn(c) {
-			result = append(result, c)
-		}
-	}
-
-	return result
-}
-
-type FilterFunc func(kube.ClusterInfo) bool
-
-func EnableAll(_ kube.ClusterInfo) bool {
-	return true
-}
-
-func ByName(pattern string) FilterFunc {
-	p := strings.TrimSpace(pattern)
-
-	if p == "" {
-		return EnableAll
-	}
-
-	re := regexp.MustCompile(".*" + p + ".*")
-
-	return func(c kube.ClusterInfo) bool {
-		return re.MatchString(c.Name)
-	}
-}
-
 // setupFlags sets flags for comand line
-func setupFlags(cmd *cobra.Command, opts *Options) {
+func setupFlags(cmd *cobra.Command, conf *action.ListConfig) {
 	flagSet := cmd.Flags()
 
-	flagSet.StringVarP(&opts.Filter, "filter", "f", "", "cluster name pattern, default list all")
+	flagSet.StringVarP(&conf.Filter, "filter", "f", "", "cluster name pattern, default list all")
+	flagSet.StringVar(&conf.Match, "match", string(matcher.Regex), "how name filter is matched, avaliable options: exact/prefix/glob/regex")
+	flagSet.StringVarP(&conf.Selector, "selector", "l", "", "label selector, e.g. env=prod,region!=us")
+	flagSet.StringVarP(&conf.Output, "output", "o", action.OutputJSON, "output format, one of table|wide|json|yaml|name")
+	flagSet.StringVar(&conf.SortBy, "sort-by", action.SortByName, "sort key, one of name|context|port|expiry")
+	flagSet.BoolVar(&conf.Managed, "managed", false, "only list clusters managed by cube")
+	flagSet.BoolVar(&conf.Unmanaged, "unmanaged", false, "only list clusters not managed by cube")
+	flagSet.BoolVar(&conf.NoHeaders, "no-headers", false, "don't print headers for table output")
+
+	_ = cmd.RegisterFlagCompletionFunc("filter", complete.Clusters)
 }
@@ -0,0 +1,42 @@
+package migrate
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+	hist "github.com/shohi/cube/pkg/history"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/log"
+)
+
+// New creates a new `migrate` subcommand.
+func New() *cobra.Command {
+	var conf action.MigrateConfig
+
+	c := &cobra.Command{
+		Use:   "migrate",
+		Short: "rename managed clusters following naming templates",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := hist.Write(); err != nil {
+				log.Warn("failed to write history", "err", err)
+			}
+			return action.Migrate(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.MigrateConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.BoolVar(&conf.DryRun, "dry-run", false, "dry-run mode. print modified config and exit")
+
+	naming := kube.DefaultNaming()
+	flagSet.StringVar(&conf.Naming.Cluster, "cluster-name-template", naming.Cluster, "Go template for cluster name, fields: .Suffix/.Host/.Port/.RemoteUser/.Context")
+	flagSet.StringVar(&conf.Naming.User, "user-name-template", naming.User, "Go template for user name, fields: .Suffix/.Host/.Port/.RemoteUser/.Context")
+	flagSet.StringVar(&conf.Naming.Context, "context-name-template", naming.Context, "Go template for context name, fields: .Suffix/.Host/.Port/.RemoteUser/.Context")
+}
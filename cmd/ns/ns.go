@@ -0,0 +1,26 @@
+package ns
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+)
+
+// New creates a new `ns` subcommand.
+func New() *cobra.Command {
+	var conf action.NsConfig
+
+	c := &cobra.Command{
+		Use:   "ns [<namespace>]",
+		Short: "switch namespace of current-context",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				conf.Namespace = args[0]
+			}
+			return action.Ns(conf)
+		},
+	}
+
+	return c
+}
@@ -0,0 +1,32 @@
+package ports
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/kube"
+)
+
+// New creates a new `ports` subcommand.
+func New() *cobra.Command {
+	var conf action.PortsConfig
+
+	c := &cobra.Command{
+		Use:   "ports",
+		Short: "show owners of local forwarding ports",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.Ports(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.PortsConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.StringVar(&conf.PortRange, "port-range", kube.DefaultPortRange().String(), "local forwarding port range, where non-cube listeners are shown. CUBE_PORT_RANGE env can be used as default")
+}
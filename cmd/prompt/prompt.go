@@ -0,0 +1,21 @@
+package prompt
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+)
+
+// New creates a new `prompt` subcommand.
+func New() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "prompt",
+		Short: "print prompt segment of current-context, used by shell-init",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.Prompt()
+		},
+	}
+
+	return c
+}
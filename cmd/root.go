@@ -23,29 +23,68 @@ THE SOFTWARE.
 package cmd
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/shohi/cube/cmd/add"
+	"github.com/shohi/cube/cmd/certs"
+	"github.com/shohi/cube/cmd/completion"
 	"github.com/shohi/cube/cmd/del"
+	"github.com/shohi/cube/cmd/doctor"
+	"github.com/shohi/cube/cmd/each"
+	"github.com/shohi/cube/cmd/env"
+	cubeexec "github.com/shohi/cube/cmd/exec"
 	"github.com/shohi/cube/cmd/forward"
+	"github.com/shohi/cube/cmd/gc"
 	"github.com/shohi/cube/cmd/history"
+	"github.com/shohi/cube/cmd/hosts"
+	"github.com/shohi/cube/cmd/label"
 	"github.com/shohi/cube/cmd/list"
+	"github.com/shohi/cube/cmd/migrate"
+	"github.com/shohi/cube/cmd/ns"
+	"github.com/shohi/cube/cmd/ports"
+	"github.com/shohi/cube/cmd/prompt"
+	"github.com/shohi/cube/cmd/rename"
+	"github.com/shohi/cube/cmd/shell"
+	"github.com/shohi/cube/cmd/shellinit"
 	"github.com/shohi/cube/cmd/show"
+	"github.com/shohi/cube/cmd/sshconfig"
+	"github.com/shohi/cube/cmd/use"
 	"github.com/shohi/cube/cmd/version"
+	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/log"
 )
 
+// logFlags holds global flags for logging.
+var logFlags struct {
+	verbose bool
+	quiet   bool
+	format  string
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "cube",
 	Short: "kubectl config manipulation tool",
+	// errors are logged by Execute.
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkFlags(); err != nil {
+			return err
+		}
+
+		return base.EnsureStateDirs()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	setupFlags(rootCmd)
 
 	rootCmd.AddCommand(history.New())
 	rootCmd.AddCommand(list.New())
@@ -54,9 +93,86 @@ func Execute() {
 	rootCmd.AddCommand(del.New())
 	rootCmd.AddCommand(forward.New())
 	rootCmd.AddCommand(show.New())
+	rootCmd.AddCommand(env.New())
+	rootCmd.AddCommand(migrate.New())
+	rootCmd.AddCommand(rename.New())
+	rootCmd.AddCommand(ports.New())
+	rootCmd.AddCommand(doctor.New())
+	rootCmd.AddCommand(certs.New())
+	rootCmd.AddCommand(label.New())
+	rootCmd.AddCommand(gc.New())
+	rootCmd.AddCommand(use.New())
+	rootCmd.AddCommand(ns.New())
+	rootCmd.AddCommand(cubeexec.New())
+	rootCmd.AddCommand(each.New())
+	rootCmd.AddCommand(shellinit.New())
+	rootCmd.AddCommand(prompt.New())
+	rootCmd.AddCommand(shell.New())
+	rootCmd.AddCommand(completion.New())
+	rootCmd.AddCommand(sshconfig.New())
+	rootCmd.AddCommand(hosts.New())
 
 	if err := rootCmd.Execute(); err != nil {
-		log.Printf("run kube error, err: %v\n", err)
+		// pass through exit code of command run by cube.
+		var exitErr *action.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
+
+		log.Error("run cube failed", "err", err)
 		os.Exit(1)
 	}
 }
+
+// setupFlags sets global flags for comand line
+func setupFlags(cmd *cobra.Command) {
+	flagSet := cmd.PersistentFlags()
+
+	flagSet.StringVar(&base.KubeConfigFlag, "kubeconfig", "", "path to the kubeconfig file. If not set, KUBECONFIG env or ~/.kube/config will be used")
+	flagSet.StringVar(&base.KubeConfigTarget, "kubeconfig-target", "", "kubeconfig file where new entries are written to. If not set, the first existing kubeconfig file will be used")
+	flagSet.StringVar(&base.StorageMode, "storage", defaultStorageMode(), "storage mode for managed clusters, avaliable options: file/split. CUBE_STORAGE env can be used as default")
+	flagSet.BoolVarP(&logFlags.verbose, "verbose", "v", false, "print debug logs")
+	flagSet.BoolVarP(&logFlags.quiet, "quiet", "q", false, "only print error logs")
+	flagSet.StringVar(&logFlags.format, "log-format", string(log.FormatText), "log format, avaliable options: text/json. Logs are written to stderr")
+}
+
+// defaultStorageMode returns storage mode from env `CUBE_STORAGE` if set.
+func defaultStorageMode() string {
+	if mode := os.Getenv("CUBE_STORAGE"); mode != "" {
+		return mode
+	}
+
+	return base.StorageFile
+}
+
+func checkFlags() error {
+	switch base.StorageMode {
+	case base.StorageFile, base.StorageSplit:
+	default:
+		return fmt.Errorf("invalid storage mode - %v", base.StorageMode)
+	}
+
+	return setupLog()
+}
+
+// setupLog sets level and format of logs from global flags.
+func setupLog() error {
+	if logFlags.verbose && logFlags.quiet {
+		return errors.New("--verbose and --quiet are mutually exclusive")
+	}
+
+	format, err := log.ParseFormat(logFlags.format)
+	if err != nil {
+		return err
+	}
+	log.SetFormat(format)
+
+	switch {
+	case logFlags.verbose:
+		log.SetLevel(log.LevelDebug)
+	case logFlags.quiet:
+		log.SetLevel(log.LevelError)
+	}
+
+	return nil
+}
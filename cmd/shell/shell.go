@@ -0,0 +1,45 @@
+package shell
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/complete"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+// New creates a new `shell` subcommand.
+func New() *cobra.Command {
+	var conf action.ShellConfig
+
+	c := &cobra.Command{
+		Use:               "shell [<name>]",
+		Short:             "spawn a subshell with its own KUBECONFIG for a cluster",
+		ValidArgsFunction: complete.FirstArg(complete.Clusters),
+		Args:              cobra.MaximumNArgs(1),
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				conf.Name = args[0]
+			}
+			return action.Shell(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.ShellConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.StringVar(&conf.Match, "match", string(matcher.Exact), "how name is matched, avaliable options: exact/prefix/glob/regex")
+	flagSet.StringVar(&conf.Shell, "shell", "", "shell to spawn. If not set, SHELL env will be used")
+	flagSet.StringVar(&conf.SSHVia, "ssh-via", "", "ssh jump server, e.g. user@jump. If not set, SSH_VIA env will be used ")
+	flagSet.DurationVar(&conf.TunnelTimeout, "tunnel-timeout", 15*time.Second, "timeout waiting for ssh tunnel to be up")
+}
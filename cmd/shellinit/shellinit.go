@@ -0,0 +1,27 @@
+package shellinit
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+)
+
+// New creates a new `shell-init` subcommand.
+func New() *cobra.Command {
+	var conf action.ShellInitConfig
+
+	c := &cobra.Command{
+		Use:   "shell-init bash|zsh|fish",
+		Short: "print shell snippet showing active cluster and tunnel state in prompt",
+		Example: `  eval "$(cube shell-init bash)"
+  cube shell-init fish | source`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf.Shell = args[0]
+			return action.ShellInit(conf)
+		},
+	}
+
+	return c
+}
@@ -5,6 +5,8 @@ import (
 	"io/ioutil"
 
 	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/log"
 	"github.com/spf13/cobra"
 )
 
@@ -19,22 +21,46 @@ func New() *cobra.Command {
 }
 
 func showLocalKubeConfig(_c *cobra.Command, _args []string) {
-	configPath := base.GetLocalKubePath()
+	paths := kube.LocalPaths()
+	if len(paths) > 1 {
+		showMergedKubeConfig(paths)
+		return
+	}
+
+	configPath := paths[0]
 	exist, isDir := base.FileExists(configPath)
 
 	if !exist {
-		fmt.Printf("config path not exits - %v\n", configPath)
+		log.Error("config path not exists", "path", configPath)
 		return
 	} else if isDir {
-		fmt.Printf("config path [%v] is a dir, not file\n", configPath)
+		log.Error("config path is a dir, not file", "path", configPath)
 		return
 	}
 
 	var err error
 	content, err := ioutil.ReadFile(configPath)
 	if err != nil {
-		fmt.Println(err)
+		log.Error("failed to show kubeconfig", "err", err)
 	} else {
 		fmt.Println(string(content))
 	}
 }
+
+// showMergedKubeConfig prints the config merged from multiple files.
+func showMergedKubeConfig(paths []string) {
+	kc, err := kube.LoadLocal()
+	if err != nil {
+		log.Error("failed to show kubeconfig", "err", err)
+		return
+	}
+
+	content, err := kube.Write(kc)
+	if err != nil {
+		log.Error("failed to show kubeconfig", "err", err)
+		return
+	}
+
+	fmt.Printf("# merged from %v\n", paths)
+	fmt.Println(string(content))
+}
@@ -0,0 +1,41 @@
+package sshconfig
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+)
+
+// New creates a new `ssh-config` subcommand.
+func New() *cobra.Command {
+	var conf action.SSHConfigConfig
+
+	c := &cobra.Command{
+		Use:   "ssh-config",
+		Short: "generate ~/.ssh/config entries for jump server and cluster masters",
+		Example: `  cube ssh-config --identity-file ~/.ssh/k8s.pem
+  cube ssh-config --local-forward --write`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.SSHConfig(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.SSHConfigConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.StringVar(&conf.Path, "path", "~/.ssh/config", "ssh config file to update")
+	flagSet.BoolVarP(&conf.Write, "write", "w", false, "update cube managed block of ssh config in place. If not set, the block is printed")
+	flagSet.StringVar(&conf.SSHVia, "ssh-via", "", "ssh jump server, e.g. user@jump. If not set, SSH_VIA env will be used ")
+	flagSet.StringVar(&conf.RemoteUser, "remote-user", "core", "user on cluster masters")
+	flagSet.StringVar(&conf.IdentityFile, "identity-file", "", "identity file for cluster masters")
+	flagSet.StringSliceVar(&conf.IPRanges, "ip-range", nil, "Host patterns of cluster masters, e.g. 172.31.*. If not set, derived from managed clusters")
+	flagSet.IntVar(&conf.SocksPort, "socks-port", 0, "reach masters via SOCKS proxy on the local port, e.g. 62222, instead of ProxyJump")
+	flagSet.BoolVar(&conf.LocalForward, "local-forward", false, "add host cube-forward forwarding local ports of all managed clusters")
+}
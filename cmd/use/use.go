@@ -0,0 +1,40 @@
+package use
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/action"
+	"github.com/shohi/cube/pkg/complete"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+// New creates a new `use` subcommand.
+func New() *cobra.Command {
+	var conf action.UseConfig
+
+	c := &cobra.Command{
+		Use:               "use [<name> | -]",
+		Short:             "switch current-context, `-` switches back to the previous one",
+		ValidArgsFunction: complete.FirstArg(complete.Clusters),
+		Args:              cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				conf.Name = args[0]
+			}
+			return action.Use(conf)
+		},
+	}
+
+	setupFlags(c, &conf)
+
+	return c
+}
+
+// setupFlags sets flags for comand line
+func setupFlags(cmd *cobra.Command, conf *action.UseConfig) {
+	flagSet := cmd.Flags()
+
+	flagSet.StringVar(&conf.Match, "match", string(matcher.Exact), "how name is matched, avaliable options: exact/prefix/glob/regex")
+	flagSet.BoolVar(&conf.Tunnel, "tunnel", false, "start ssh port forwarding if it isn't running")
+	flagSet.StringVar(&conf.SSHVia, "ssh-via", "", "ssh jump server, e.g. user@jump. If not set, SSH_VIA env will be used ")
+}
@@ -6,10 +6,14 @@ require (
 	github.com/StackExchange/wmi v0.0.0-20190523213315-cbe66965904d // indirect
 	github.com/atrox/homedir v1.0.0
 	github.com/go-ole/go-ole v1.2.4 // indirect
+	github.com/manifoldco/promptui v0.9.0
 	github.com/mitchellh/go-homedir v1.1.0
+	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pkg/errors v0.9.1
 	github.com/shirou/gopsutil v2.20.2+incompatible
-	github.com/spf13/cobra v0.0.6
+	github.com/spf13/cobra v1.1.3
 	github.com/stretchr/testify v1.3.0
+	k8s.io/apimachinery v0.16.4
 	k8s.io/client-go v0.16.4
+	sigs.k8s.io/yaml v1.1.0
 )
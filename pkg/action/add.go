@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/cube"
 	"github.com/shohi/cube/pkg/kube"
 )
 
@@ -19,45 +19,52 @@ type AddConfig struct {
 	DryRun bool
 	Force  bool
 
+	Naming     kube.Naming
+	OnConflict string
+	PortRange  string
+	Labels     string
+
 	PrintSSHForwarding bool
 }
 
 // TODO: test
 // Add adds new kubectl config.
 func Add(conf AddConfig) error {
-	remoteAddr := base.SshHost(conf.RemoteUser, conf.RemoteIP)
-
-	opts := kube.MergeOptions{
-		RemoteAddr: remoteAddr,
-		NameSuffix: conf.NameSuffix,
+	res, err := newClient(conf.SSHVia).Add(cube.AddOptions{
+		RemoteUser: conf.RemoteUser,
+		RemoteIP:   conf.RemoteIP,
 		LocalPort:  conf.LocalPort,
+		NameSuffix: conf.NameSuffix,
+		DryRun:     conf.DryRun || conf.PrintSSHForwarding,
 		Force:      conf.Force,
-	}
-	m := kube.NewMerger(opts)
-	if err := m.Merge(); err != nil {
+		Naming:     conf.Naming,
+		OnConflict: conf.OnConflict,
+		PortRange:  conf.PortRange,
+		Labels:     conf.Labels,
+	})
+	if err != nil {
 		return err
 	}
 
-	localPort := m.LocalPort()
-	apiAddr := m.RemoteAPIAddr()
-	sshCmd := kube.GetPortForwardingCmd(localPort, apiAddr, conf.SSHVia)
 	if conf.PrintSSHForwarding {
 		// NOTE: Print SSH forwarding setting
-		fmt.Fprintf(os.Stdout, "# ssh forwarding command\n%s\n", sshCmd)
+		fmt.Fprintf(os.Stdout, "# ssh forwarding command\n%s\n", res.SSHForward)
 		return nil
 	}
 
 	// always output updated kubeconfig.
-	content, err := kube.Write(m.Result())
+	content, err := kube.Write(res.Config)
 	if err != nil {
 		return err
 	}
 
 	fmt.Fprintf(os.Stdout, "# updated config\n%v\n", string(content))
-	fmt.Fprintf(os.Stdout, "# ssh forwarding command\n%s\n", sshCmd)
+	fmt.Fprintf(os.Stdout, "# ssh forwarding command\n%s\n", res.SSHForward)
+
+	fmt.Fprintf(os.Stdout, "# merge summary\n%v", res.Summary)
 
-	if !conf.DryRun {
-		kube.WriteToFile(m.Result(), base.GetLocalKubePath())
+	if res.Saved {
+		printSplitEnv()
 	}
 
 	return nil
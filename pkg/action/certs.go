@@ -0,0 +1,43 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/shohi/cube/pkg/kube"
+)
+
+type CertsConfig struct {
+	Window       string
+	ExpiringOnly bool
+}
+
+// Certs lists CA and client certificate expiry for every managed cluster.
+func Certs(conf CertsConfig) error {
+	w, err := kube.ParseCertWindow(conf.Window)
+	if err != nil {
+		return err
+	}
+
+	infos, err := kube.ListCerts(w)
+	if err != nil {
+		return err
+	}
+
+	selected := make([]kube.CertInfo, 0, len(infos))
+	for _, info := range infos {
+		if conf.ExpiringOnly && !info.Expiring && !info.Expired {
+			continue
+		}
+		selected = append(selected, info)
+	}
+
+	content, err := json.MarshalIndent(selected, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stdout, string(content))
+	return nil
+}
@@ -0,0 +1,24 @@
+package action
+
+import (
+	"os"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/cube"
+	"github.com/shohi/cube/pkg/log"
+)
+
+// newClient creates client from global flags, with output of commands run
+// by cube going to stdout and stderr, and logs to the global logger.
+func newClient(via string) *cube.Client {
+	return cube.New(cube.Options{
+		KubeConfig:       base.KubeConfigFlag,
+		KubeConfigTarget: base.KubeConfigTarget,
+		Storage:          base.StorageMode,
+		StateDir:         base.StateDir(),
+		SSHVia:           via,
+		Stdout:           os.Stdout,
+		Stderr:           os.Stderr,
+		Logger:           log.Default(),
+	})
+}
@@ -0,0 +1,51 @@
+package action
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+var (
+	errConfirmRequired = errors.New("cube: confirmation required but stdin is not a terminal, use --yes to proceed")
+	errAborted         = errors.New("cube: aborted")
+)
+
+// confirm asks for confirmation on terminal, which is skipped if yes is true.
+func confirm(prompt string, yes bool) error {
+	if yes {
+		return nil
+	}
+
+	if !base.IsTerminal(os.Stdin) {
+		return errConfirmRequired
+	}
+
+	if !askConfirm(os.Stdin, os.Stderr, prompt) {
+		return errAborted
+	}
+
+	return nil
+}
+
+// askConfirm prints prompt and returns true only if answer is yes.
+func askConfirm(r io.Reader, w io.Writer, prompt string) bool {
+	fmt.Fprintf(w, "%v [y/N]: ", prompt)
+
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
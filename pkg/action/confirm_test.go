@@ -0,0 +1,34 @@
+package action
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAskConfirm(t *testing.T) {
+	tests := []struct {
+		answer   string
+		expected bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{" yes ", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.answer, func(t *testing.T) {
+			var out bytes.Buffer
+			ok := askConfirm(strings.NewReader(test.answer), &out, "delete?")
+			assert.Equal(t, test.expected, ok)
+			assert.Equal(t, "delete? [y/N]: ", out.String())
+		})
+	}
+
+	assert.Nil(t, confirm("delete?", true))
+}
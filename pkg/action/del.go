@@ -4,40 +4,70 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/cube"
 	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/matcher"
+	"github.com/shohi/cube/pkg/picker"
 )
 
 type DelConfig struct {
-	Name   string
-	All    bool
-	DryRun bool
+	Name     string
+	Selector string
+	Match    string
+	All      bool
+	DryRun   bool
+
+	Yes          bool // skip confirmation
+	AllowCurrent bool // allow to delete current-context
 }
 
 // Del remove specified kubectl config
 func Del(conf DelConfig) error {
-	opts := kube.PurgeOptions{
-		Name: conf.Name,
-		All:  conf.All,
+	c := newClient("")
+
+	if conf.Name == "" && conf.Selector == "" && picker.Available() {
+		ctxName, err := pickContext(c, "Delete cluster")
+		if err != nil {
+			return err
+		}
+		conf.Name, conf.Match = ctxName, string(matcher.Exact)
 	}
 
-	p := kube.NewPurger(opts)
-	if err := p.Purge(); err != nil {
+	p, err := c.PlanDelete(cube.DeleteOptions{
+		Name:         conf.Name,
+		Selector:     conf.Selector,
+		Match:        conf.Match,
+		All:          conf.All,
+		AllowCurrent: conf.AllowCurrent,
+	})
+	if err != nil {
 		return err
 	}
 
-	// always output updated kubeconfig.
-	content, err := kube.Write(p.Result())
-	if err != nil {
+	if conf.DryRun {
+		content, err := kube.Write(p.Config)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "# updated config\n%v\n", string(content))
+	}
+
+	fmt.Fprintf(os.Stdout, "# to be deleted\n%v", p.Plan)
+	if conf.DryRun {
+		return nil
+	}
+
+	if err := confirm("Delete above?", conf.Yes); err != nil {
 		return err
 	}
 
-	fmt.Fprintf(os.Stdout, "# updated config\n%v\n", string(content))
-	if !conf.DryRun {
-		kube.WriteToFile(p.Result(), base.GetLocalKubePath())
+	deleted, err := p.Apply()
+	if err != nil {
+		return err
 	}
+	printSplitEnv()
 
-	fmt.Fprintf(os.Stdout, "# cluster deleted\n%v\n", p.Deleted())
+	fmt.Fprintf(os.Stdout, "# cluster deleted\n%v\n", deleted)
 
 	return nil
 }
@@ -0,0 +1,26 @@
+package action
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/shohi/cube/pkg/doctor"
+)
+
+type DoctorConfig struct {
+	Fix bool
+}
+
+// Doctor diagnoses local setup and cube state.
+func Doctor(conf DoctorConfig) error {
+	results := doctor.Run(conf.Fix)
+	for _, r := range results {
+		fmt.Fprintln(os.Stdout, r)
+	}
+
+	if n := doctor.Failed(results); n > 0 {
+		return fmt.Errorf("doctor: %d checks failed", n)
+	}
+
+	return nil
+}
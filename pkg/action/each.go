@@ -0,0 +1,207 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/shohi/cube/pkg/cube"
+	"github.com/shohi/cube/pkg/kube"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Output modes for each
+const (
+	EachOutputPrefix = "prefix" // prefix every line with cluster name
+	EachOutputGroup  = "group"  // print output of a cluster together when it's done
+)
+
+var (
+	errEmptyEachTarget   = errors.New("each: empty cluster name and selector")
+	errInvalidEachOutput = errors.New("each: invalid output mode, must be one of prefix|group")
+	errInvalidParallel   = errors.New("each: parallel must be positive")
+)
+
+type EachConfig struct {
+	Name     string
+	Match    string
+	Selector string
+	Exclude  string
+	Args     []string // command and its args
+
+	Parallel      int
+	Output        string
+	SSHVia        string
+	TunnelTimeout time.Duration
+}
+
+// eachResult is the result of running command against a cluster.
+type eachResult struct {
+	name string
+	err  error
+}
+
+// Each runs command against every selected cluster with bounded
+// concurrency, and prints a summary. Tunnels are managed as in Exec.
+func Each(conf EachConfig) error {
+	if len(conf.Args) == 0 {
+		return errEmptyCommand
+	}
+	if conf.Name == "" && conf.Selector == "" {
+		return errEmptyEachTarget
+	}
+	if conf.Parallel <= 0 {
+		return errInvalidParallel
+	}
+	if conf.Output != EachOutputPrefix && conf.Output != EachOutputGroup {
+		return errInvalidEachOutput
+	}
+
+	// clusters are selected as in forward, so that exclusions apply alike.
+	c := newClient(conf.SSHVia)
+	names, err := c.Select(cube.ForwardOptions{
+		Name:     conf.Name,
+		Selector: conf.Selector,
+		Match:    conf.Match,
+		Exclude:  conf.Exclude,
+	})
+	if err != nil {
+		return err
+	}
+
+	kc, metas, err := c.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, conf.Parallel)
+	results := make([]eachResult, len(names))
+
+	for i, ctxName := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, ctxName string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			name := kube.ShortName(metas, ctxName)
+			results[i] = eachResult{
+				name: name,
+				err:  runEach(c, kc, ctxName, name, conf, &mu),
+			}
+		}(i, ctxName)
+	}
+	wg.Wait()
+
+	return printEachSummary(os.Stdout, results)
+}
+
+// runEach runs command against a cluster, and writes its output following
+// output mode. Writes to stdout are serialized by mu.
+func runEach(c *cube.Client, kc *clientcmdapi.Config, ctxName, name string, conf EachConfig, mu *sync.Mutex) error {
+	sess, err := openSession(c, kc, ctxName, conf.TunnelTimeout)
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+
+	cmd := sess.Command(conf.Args)
+
+	var buf bytes.Buffer
+	if conf.Output == EachOutputGroup {
+		cmd.Stdout, cmd.Stderr = &buf, &buf
+		defer func() {
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintf(os.Stdout, "# %v\n%s", name, buf.Bytes())
+		}()
+	} else {
+		w := newPrefixWriter(os.Stdout, fmt.Sprintf("[%v] ", name), mu)
+		defer w.Flush()
+		cmd.Stdout, cmd.Stderr = w, w
+	}
+
+	return toExitError(cmd.Run())
+}
+
+func printEachSummary(w io.Writer, results []eachResult) error {
+	var failed int
+
+	fmt.Fprintln(w, "# summary")
+	for _, r := range results {
+		if r.err == nil {
+			fmt.Fprintf(w, "PASS\t%v\n", r.name)
+			continue
+		}
+
+		failed++
+		fmt.Fprintf(w, "FAIL\t%v\t%v\n", r.name, r.err)
+	}
+	fmt.Fprintf(w, "%d passed, %d failed\n", len(results)-failed, failed)
+
+	if failed > 0 {
+		return &ExitError{Code: 1}
+	}
+
+	return nil
+}
+
+// prefixWriter prefixes every line, and writes whole lines to out under mu,
+// so that lines from concurrent writers don't interleave.
+type prefixWriter struct {
+	out    io.Writer
+	prefix string
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+func newPrefixWriter(out io.Writer, prefix string, mu *sync.Mutex) *prefixWriter {
+	return &prefixWriter{out: out, prefix: prefix, mu: mu}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+
+		w.writeLine(w.buf[:idx+1])
+		w.buf = w.buf[idx+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes remaining partial line.
+func (w *prefixWriter) Flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+
+	w.writeLine(append(w.buf, '\n'))
+	w.buf = nil
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+}
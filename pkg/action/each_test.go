@@ -0,0 +1,48 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	var out bytes.Buffer
+	var mu sync.Mutex
+	w := newPrefixWriter(&out, "[qa] ", &mu)
+
+	w.Write([]byte("hello\nwor"))
+	w.Write([]byte("ld\npartial"))
+	assert.Equal("[qa] hello\n[qa] world\n", out.String())
+
+	w.Flush()
+	assert.Equal("[qa] hello\n[qa] world\n[qa] partial\n", out.String())
+}
+
+func TestPrintEachSummary(t *testing.T) {
+	assert := assert.New(t)
+
+	var out bytes.Buffer
+	err := printEachSummary(&out, []eachResult{
+		{name: "prod-a"},
+		{name: "prod-b", err: &ExitError{Code: 2}},
+		{name: "prod-c", err: errors.New("tunnel failed")},
+	})
+
+	var exitErr *ExitError
+	assert.True(errors.As(err, &exitErr))
+	assert.Equal(1, exitErr.Code)
+	assert.Equal("# summary\n"+
+		"PASS\tprod-a\n"+
+		"FAIL\tprod-b\texit status 2\n"+
+		"FAIL\tprod-c\ttunnel failed\n"+
+		"1 passed, 2 failed\n", out.String())
+
+	out.Reset()
+	assert.Nil(printEachSummary(&out, []eachResult{{name: "prod-a"}}))
+}
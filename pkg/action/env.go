@@ -0,0 +1,52 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+)
+
+var (
+	errUnsupportedShell = errors.New("env: unsupported shell")
+)
+
+type EnvConfig struct {
+	Shell string
+}
+
+// Env prints shell snippet which sets KUBECONFIG to cover all local
+// kubeconfig files, including per-cluster files in split mode.
+func Env(conf EnvConfig) error {
+	snippet, err := genEnvSnippet(conf.Shell, kube.SplitKubeConfigEnv())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stdout, snippet)
+	return nil
+}
+
+func genEnvSnippet(shell, value string) (string, error) {
+	switch strings.ToLower(shell) {
+	case "", "bash", "zsh", "sh":
+		return fmt.Sprintf("export %s=%q\n# run: eval \"$(cube env)\"", base.KubeConfigEnv, value), nil
+	case "fish":
+		return fmt.Sprintf("set -gx %s %q\n# run: cube env --shell fish | source", base.KubeConfigEnv, value), nil
+	default:
+		return "", fmt.Errorf("%w - %v", errUnsupportedShell, shell)
+	}
+}
+
+// printSplitEnv prints the updated KUBECONFIG in split mode, as the list
+// of per-cluster files may have changed.
+func printSplitEnv() {
+	if !base.IsSplitStorage() {
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "# kubeconfig env\nexport %s=%q\n", base.KubeConfigEnv, kube.SplitKubeConfigEnv())
+}
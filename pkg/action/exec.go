@@ -0,0 +1,142 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"time"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/cube"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/matcher"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var (
+	errEmptyCommand = errors.New("exec: empty command")
+)
+
+// ExitError carries exit code of a command run by cube.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+type ExecConfig struct {
+	Name  string
+	Match string
+	Args  []string // command and its args
+
+	SSHVia        string
+	TunnelTimeout time.Duration
+}
+
+// Exec runs command against given cluster with a temporary kubeconfig,
+// without touching current-context. Tunnel is started if it isn't
+// running, and stopped after the command exits.
+func Exec(conf ExecConfig) error {
+	if len(conf.Args) == 0 {
+		return errEmptyCommand
+	}
+
+	c := newClient(conf.SSHVia)
+	kc, metas, err := c.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := matcher.Parse(conf.Match, conf.Name)
+	if err != nil {
+		return err
+	}
+
+	ctxName, err := kube.ResolveContext(kc, metas, m)
+	if err != nil {
+		return err
+	}
+
+	// the command handles interrupts itself, cube waits for it to clean up.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	sess, err := openSession(c, kc, ctxName, conf.TunnelTimeout)
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+
+	cmd := sess.Command(conf.Args)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	return toExitError(cmd.Run())
+}
+
+// toExitError converts exit error of command to ExitError.
+func toExitError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Code: exitErr.ExitCode()}
+	}
+
+	return err
+}
+
+// session holds a temporary kubeconfig and the tunnel started for running
+// commands against a cluster.
+type session struct {
+	ctxName    string
+	configPath string
+	forward    *cube.ForwardResult
+}
+
+// openSession writes temporary kubeconfig for given context, and starts
+// tunnel by client if it's a managed cluster whose tunnel isn't running.
+func openSession(c *cube.Client, kc *clientcmdapi.Config, ctxName string, timeout time.Duration) (*session, error) {
+	minKC, err := kube.MinimalConfig(kc, ctxName)
+	if err != nil {
+		return nil, err
+	}
+
+	configPath, err := kube.WriteTempConfig(minKC)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &session{ctxName: ctxName, configPath: configPath}
+
+	s.forward, err = c.Forward(cube.ForwardOptions{
+		Context:       ctxName,
+		Operation:     cube.OpTunnel,
+		TunnelTimeout: timeout,
+	})
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Command creates command with KUBECONFIG pointing to the temporary kubeconfig.
+func (s *session) Command(args []string) *exec.Cmd {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), base.KubeConfigEnv+"="+s.configPath)
+
+	return cmd
+}
+
+// Close stops the tunnel started by session, and removes the temporary kubeconfig.
+func (s *session) Close() {
+	if s.forward != nil {
+		s.forward.Close()
+	}
+
+	os.Remove(s.configPath)
+}
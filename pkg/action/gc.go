@@ -0,0 +1,57 @@
+package action
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/log"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+type GCConfig struct {
+	DryRun bool
+	Yes    bool // skip confirmation
+}
+
+// GC removes dangling contexts, clusters and users from kubeconfig.
+func GC(conf GCConfig) error {
+	c := newClient("")
+	kc, metas, err := c.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	g := kube.FindGarbage(kc)
+	if g.Empty() {
+		log.Info("nothing to collect")
+		return nil
+	}
+
+	fmt.Fprintf(os.Stdout, "# dangling entries\n%v", g)
+	if conf.DryRun {
+		return nil
+	}
+
+	if err := confirm("Remove above?", conf.Yes); err != nil {
+		return err
+	}
+
+	return removeGarbage(c.Store(), c.State(), kc, metas, g)
+}
+
+// removeGarbage removes dangling entries from kubeconfig and their
+// metadata, and saves both.
+func removeGarbage(store *kube.Store, state base.State, kc *clientcmdapi.Config, metas kube.Metas, g kube.Garbage) error {
+	g.Remove(kc)
+	if err := store.Save(kc); err != nil {
+		return err
+	}
+
+	for _, k := range g.Contexts {
+		delete(metas, k)
+	}
+
+	return metas.SaveTo(state.MetaPath())
+}
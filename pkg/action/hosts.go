@@ -0,0 +1,124 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/log"
+)
+
+// Operations of hosts
+const (
+	HostsCheck   = "check"
+	HostsInstall = "install"
+	HostsRemove  = "remove"
+)
+
+var (
+	errInvalidHostsOp   = errors.New("hosts: invalid operation")
+	errHostsPermission  = errors.New("hosts: permission denied, re-run with --sudo")
+	errHostMappingCheck = errors.New("hosts: host mapping check failed")
+)
+
+type HostsConfig struct {
+	Operation string
+	Path      string // hosts file
+	Sudo      bool   // write hosts file with sudo
+	DryRun    bool
+}
+
+// Hosts checks, installs or removes the entry mapping `kubernetes` to
+// loopback, which ssh port forwarding of managed clusters depends on.
+func Hosts(conf HostsConfig) error {
+	if conf.Path == "" {
+		conf.Path = kube.HostsFile()
+	}
+
+	switch conf.Operation {
+	case HostsCheck:
+		return checkHosts(conf)
+	case HostsInstall, HostsRemove:
+		return updateHosts(conf)
+	default:
+		return fmt.Errorf("%w - %v", errInvalidHostsOp, conf.Operation)
+	}
+}
+
+func checkHosts(conf HostsConfig) error {
+	content, err := base.ReadFileString(conf.Path)
+	if err != nil {
+		return err
+	}
+
+	if start, _ := kube.HostsBlock.Find(content); start >= 0 {
+		log.Info("cube managed entry found", "path", conf.Path)
+	}
+
+	addrs, err := kube.CheckHostMapping()
+	if err != nil {
+		return fmt.Errorf("%w - %v, run: cube hosts install --sudo", errHostMappingCheck, err)
+	}
+
+	fmt.Fprintf(os.Stdout, "%v => %v\n", kube.DefaultHost, strings.Join(addrs, ", "))
+	return nil
+}
+
+func updateHosts(conf HostsConfig) error {
+	content, err := base.ReadFileString(conf.Path)
+	if err != nil {
+		return err
+	}
+
+	var updated string
+	if conf.Operation == HostsInstall {
+		if kube.HasHostMapping(kube.HostsBlock.Remove(content)) {
+			log.Info("host already mapped to loopback", "host", kube.DefaultHost, "path", conf.Path)
+			return nil
+		}
+		updated = kube.HostsBlock.Upsert(content, kube.HostsEntry())
+	} else {
+		updated = kube.HostsBlock.Remove(content)
+	}
+
+	if updated == content {
+		log.Info("hosts file is up to date", "path", conf.Path)
+		return nil
+	}
+
+	if conf.DryRun {
+		fmt.Fprint(os.Stdout, updated)
+		return nil
+	}
+
+	if err := writeHostsFile(conf.Path, updated, conf.Sudo); err != nil {
+		return err
+	}
+
+	log.Info("hosts file updated", "op", conf.Operation, "path", conf.Path)
+	return nil
+}
+
+// writeHostsFile writes hosts file, with `sudo tee` if required.
+func writeHostsFile(p, content string, sudo bool) error {
+	if sudo {
+		cmd := exec.Command("sudo", "tee", p)
+		cmd.Stdin = strings.NewReader(content)
+		cmd.Stdout = ioutil.Discard
+		cmd.Stderr = os.Stderr
+
+		return cmd.Run()
+	}
+
+	err := base.WriteFileString(p, content, 0644)
+	if os.IsPermission(err) {
+		return fmt.Errorf("%w - %v", errHostsPermission, p)
+	}
+
+	return err
+}
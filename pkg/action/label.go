@@ -0,0 +1,88 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/shohi/cube/pkg/cube"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+var (
+	errEmptyLabelTarget = errors.New("label: empty cluster name and selector")
+)
+
+type LabelConfig struct {
+	Name     string
+	Selector string
+	Match    string
+
+	// Changes are labels to set (`key=value`) or remove (`key-`).
+	// If empty, current labels are shown.
+	Changes   []string
+	Overwrite bool
+	DryRun    bool
+}
+
+// Label attaches labels to or removes labels from clusters, which are
+// stored in cube metadata.
+func Label(conf LabelConfig) error {
+	if conf.Name == "" && conf.Selector == "" {
+		return errEmptyLabelTarget
+	}
+
+	sel, err := kube.ParseSelector(conf.Selector)
+	if err != nil {
+		return err
+	}
+
+	m, err := matcher.Parse(conf.Match, conf.Name)
+	if err != nil {
+		return err
+	}
+
+	set, remove, err := kube.ParseLabelChanges(conf.Changes)
+	if err != nil {
+		return err
+	}
+
+	c := newClient("")
+	kc, metas, err := c.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctxs := kube.SelectContexts(kube.FindContexts(kc, metas, m, nil), metas, sel)
+	if len(ctxs) == 0 {
+		return cube.ErrClusterNotFound
+	}
+
+	names := make([]string, 0, len(ctxs))
+	for k := range ctxs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	if len(conf.Changes) > 0 {
+		for _, k := range names {
+			if err := kube.ApplyLabels(kc, metas, k, set, remove, conf.Overwrite); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, k := range names {
+		fmt.Fprintf(os.Stdout, "%v\t%v\n", k, labels.Set(metas.Labels(k)))
+	}
+
+	if len(conf.Changes) == 0 || conf.DryRun {
+		return nil
+	}
+
+	return metas.SaveTo(c.State().MetaPath())
+}
@@ -0,0 +1,202 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
+	"github.com/shohi/cube/pkg/cube"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/log"
+)
+
+// Output formats for list
+const (
+	OutputTable = "table"
+	OutputWide  = "wide"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputName  = "name"
+)
+
+// Sort keys for list
+const (
+	SortByName    = "name"
+	SortByContext = "context"
+	SortByPort    = "port"
+	SortByExpiry  = "expiry"
+)
+
+var (
+	errInvalidOutput = errors.New("cube: invalid output format, must be one of table|wide|json|yaml|name")
+	errInvalidSortBy = errors.New("cube: invalid sort key, must be one of name|context|port|expiry")
+	errManagedFilter = errors.New("cube: --managed and --unmanaged are mutually exclusive")
+)
+
+type ListConfig struct {
+	Filter    string // name filter
+	Match     string // how name filter is matched
+	Selector  string // label selector
+	Output    string
+	SortBy    string
+	NoHeaders bool
+
+	Managed   bool // only clusters managed by cube
+	Unmanaged bool // only clusters not managed by cube
+}
+
+// List prints clusters in given output format.
+func List(conf ListConfig) error {
+	if err := validateListConfig(conf); err != nil {
+		return err
+	}
+
+	res, err := newClient("").List(cube.ListOptions{
+		Name:      conf.Filter,
+		Match:     conf.Match,
+		Selector:  conf.Selector,
+		Managed:   conf.Managed,
+		Unmanaged: conf.Unmanaged,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, w := range res.Warnings {
+		log.Warn("skip broken context", "context", w.Context, "reason", w.Reason)
+	}
+
+	sortClusters(res.Clusters, conf.SortBy)
+
+	return printClusters(os.Stdout, res.Clusters, conf)
+}
+
+func validateListConfig(conf ListConfig) error {
+	switch conf.Output {
+	case OutputTable, OutputWide, OutputJSON, OutputYAML, OutputName:
+	default:
+		return errors.Wrapf(errInvalidOutput, "output: %v", conf.Output)
+	}
+
+	if conf.Managed && conf.Unmanaged {
+		return errManagedFilter
+	}
+
+	switch conf.SortBy {
+	case "", SortByName, SortByContext, SortByPort, SortByExpiry:
+	default:
+		return errors.Wrapf(errInvalidSortBy, "sort-by: %v", conf.SortBy)
+	}
+
+	return nil
+}
+
+// sortClusters sorts clusters by given key, name is used as tie breaker.
+// Clusters without cert expiry are put last when sorting by expiry.
+func sortClusters(s kube.ClusterInfos, key string) {
+	less := func(i, j int) bool { return s[i].Name < s[j].Name }
+
+	switch key {
+	case SortByContext:
+		less = func(i, j int) bool { return s[i].Context < s[j].Context }
+	case SortByPort:
+		less = func(i, j int) bool {
+			if s[i].LocalPort != s[j].LocalPort {
+				return s[i].LocalPort < s[j].LocalPort
+			}
+			return s[i].Name < s[j].Name
+		}
+	case SortByExpiry:
+		less = func(i, j int) bool {
+			a, b := s[i].CertExpiry, s[j].CertExpiry
+			if a == b {
+				return s[i].Name < s[j].Name
+			}
+			if a == "" || b == "" {
+				return b == ""
+			}
+			return a < b
+		}
+	}
+
+	sort.SliceStable(s, less)
+}
+
+func printClusters(w io.Writer, s kube.ClusterInfos, conf ListConfig) error {
+	switch conf.Output {
+	case OutputJSON:
+		content, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(content))
+	case OutputYAML:
+		content, err := yaml.Marshal(s)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, string(content))
+	case OutputName:
+		for _, c := range s {
+			fmt.Fprintln(w, c.Name)
+		}
+	default:
+		printClusterTable(w, s, conf.Output == OutputWide, conf.NoHeaders)
+	}
+
+	return nil
+}
+
+func printClusterTable(w io.Writer, s kube.ClusterInfos, wide bool, noHeaders bool) {
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+
+	headers := []string{"NAME", "TYPE", "LOCAL PORT", "REMOTE API", "TUNNEL", "CERT EXPIRY"}
+	if wide {
+		headers = []string{"CURRENT", "CONTEXT", "NAME", "TYPE", "LOCAL PORT", "REMOTE API",
+			"JUMP HOST", "AUTH", "TUNNEL", "CERT EXPIRY", "LABELS"}
+	}
+
+	if !noHeaders {
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	}
+
+	for _, c := range s {
+		port := ""
+		if c.LocalPort > 0 {
+			port = strconv.Itoa(c.LocalPort)
+		}
+
+		row := []string{c.Name, c.Type, orNone(port), orNone(c.RemoteAPIAddr),
+			orNone(c.Tunnel), orNone(c.CertExpiry)}
+		if wide {
+			current := ""
+			if c.Current {
+				current = "*"
+			}
+			row = []string{current, c.Context, c.Name, c.Type, orNone(port),
+				orNone(c.RemoteAPIAddr), orNone(c.JumpHost), orNone(c.AuthType),
+				orNone(c.Tunnel), orNone(c.CertExpiry), orNone(labels.Set(c.Labels).String())}
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	tw.Flush()
+}
+
+// orNone returns `<none>` for empty table cell.
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+
+	return s
+}
@@ -0,0 +1,83 @@
+package action
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shohi/cube/pkg/kube"
+)
+
+func TestSortClusters(t *testing.T) {
+	newInfos := func() kube.ClusterInfos {
+		return kube.ClusterInfos{
+			{Name: "b", Context: "ctx-a", LocalPort: 7001, CertExpiry: ""},
+			{Name: "a", Context: "ctx-c", LocalPort: 7003, CertExpiry: "2021-01-01"},
+			{Name: "c", Context: "ctx-b", LocalPort: 7002, CertExpiry: "2020-06-01"},
+		}
+	}
+
+	tests := []struct {
+		key   string
+		names []string
+	}{
+		{SortByName, []string{"a", "b", "c"}},
+		{SortByContext, []string{"b", "c", "a"}},
+		{SortByPort, []string{"b", "c", "a"}},
+		{SortByExpiry, []string{"c", "a", "b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			infos := newInfos()
+			sortClusters(infos, test.key)
+
+			var names []string
+			for _, c := range infos {
+				names = append(names, c.Name)
+			}
+			assert.Equal(t, test.names, names)
+		})
+	}
+}
+
+func TestPrintClusters(t *testing.T) {
+	infos := kube.ClusterInfos{
+		{Name: "172.31.7.182-qa", Type: kube.TypeCubeTunnel, Context: "qa", LocalPort: 7001, RemoteAPIAddr: "172.31.7.182:6443",
+			AuthType: kube.AuthToken, Current: true, Tunnel: kube.TunnelDown, Labels: map[string]string{"env": "prod"}},
+	}
+
+	tests := []struct {
+		name      string
+		output    string
+		noHeaders bool
+		expected  string
+	}{
+		{"name", OutputName, false, "172.31.7.182-qa\n"},
+		{"table-no-headers", OutputTable, true,
+			"172.31.7.182-qa   cube-tunnel   7001   172.31.7.182:6443   down   <none>\n"},
+		{"wide", OutputWide, false,
+			"CURRENT   CONTEXT   NAME              TYPE          LOCAL PORT   REMOTE API          JUMP HOST   AUTH    TUNNEL   CERT EXPIRY   LABELS\n" +
+				"*         qa        172.31.7.182-qa   cube-tunnel   7001         172.31.7.182:6443   <none>      token   down     <none>        env=prod\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var buf bytes.Buffer
+			err := printClusters(&buf, infos, ListConfig{Output: test.output, NoHeaders: test.noHeaders})
+			assert.Nil(err)
+			assert.Equal(test.expected, buf.String())
+		})
+	}
+}
+
+func TestValidateListConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(validateListConfig(ListConfig{Output: OutputYAML, SortBy: SortByPort}))
+	assert.NotNil(validateListConfig(ListConfig{Output: "xml"}))
+	assert.NotNil(validateListConfig(ListConfig{Output: OutputJSON, SortBy: "age"}))
+}
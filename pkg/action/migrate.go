@@ -0,0 +1,54 @@
+package action
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/shohi/cube/pkg/kube"
+)
+
+type MigrateConfig struct {
+	Naming kube.Naming
+	DryRun bool
+}
+
+// Migrate renames managed clusters following naming templates.
+func Migrate(conf MigrateConfig) error {
+	c := newClient("")
+	m := kube.NewMigrator(kube.MigrateOptions{
+		Naming:   conf.Naming,
+		Store:    c.Store(),
+		StateDir: c.State().Dir,
+	})
+	if err := m.Migrate(); err != nil {
+		return err
+	}
+
+	// always output updated kubeconfig.
+	content, err := kube.Write(m.Result())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "# updated config\n%v\n", string(content))
+	if !conf.DryRun {
+		if err := m.Save(); err != nil {
+			return err
+		}
+	}
+
+	renamed := m.Renamed()
+	olds := make([]string, 0, len(renamed))
+	for k := range renamed {
+		olds = append(olds, k)
+	}
+	sort.Strings(olds)
+
+	fmt.Fprintf(os.Stdout, "# context renamed\n")
+	for _, k := range olds {
+		fmt.Fprintf(os.Stdout, "%v => %v\n", k, renamed[k])
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package action
+
+import (
+	"github.com/shohi/cube/pkg/cube"
+	"github.com/shohi/cube/pkg/picker"
+)
+
+// pickContext lets user pick a cluster managed by client interactively,
+// and returns its context name.
+func pickContext(c *cube.Client, label string) (string, error) {
+	res, err := c.List(cube.ListOptions{Managed: true})
+	if err != nil {
+		return "", err
+	}
+
+	info, err := picker.Pick(label, res.Clusters)
+	if err != nil {
+		return "", err
+	}
+
+	return info.Context, nil
+}
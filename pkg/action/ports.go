@@ -0,0 +1,34 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/shohi/cube/pkg/kube"
+)
+
+type PortsConfig struct {
+	PortRange string
+}
+
+// Ports shows who owns which local port.
+func Ports(conf PortsConfig) error {
+	r, err := kube.ParsePortRange(conf.PortRange)
+	if err != nil {
+		return err
+	}
+
+	infos, err := kube.ListPorts(r)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stdout, string(content))
+	return nil
+}
@@ -0,0 +1,50 @@
+package action
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/shohi/cube/pkg/kube"
+)
+
+type RenameConfig struct {
+	OldSuffix string
+	NewSuffix string
+
+	Naming kube.Naming
+	DryRun bool
+}
+
+// Rename changes name suffix of a managed cluster in place.
+func Rename(conf RenameConfig) error {
+	c := newClient("")
+	r := kube.NewRenamer(kube.RenameOptions{
+		OldSuffix: conf.OldSuffix,
+		NewSuffix: conf.NewSuffix,
+		Naming:    conf.Naming,
+		Store:     c.Store(),
+		StateDir:  c.State().Dir,
+	})
+	if err := r.Rename(); err != nil {
+		return err
+	}
+
+	// always output updated kubeconfig.
+	content, err := kube.Write(r.Result())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "# updated config\n%v\n", string(content))
+	if !conf.DryRun {
+		if err := r.Save(); err != nil {
+			return err
+		}
+		printSplitEnv()
+	}
+
+	oldCtx, newCtx := r.Renamed()
+	fmt.Fprintf(os.Stdout, "# context renamed\n%v => %v\n", oldCtx, newCtx)
+
+	return nil
+}
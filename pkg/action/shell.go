@@ -0,0 +1,187 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/log"
+	"github.com/shohi/cube/pkg/matcher"
+	"github.com/shohi/cube/pkg/picker"
+)
+
+// CubeShellEnv is set to context name in shell spawned by `cube shell`.
+const CubeShellEnv = "CUBE_SHELL"
+
+var (
+	errEmptyShellName = errors.New("shell: empty cluster name")
+	errNestedShell    = errors.New("shell: already in a cube shell, exit it first")
+)
+
+type ShellInitConfig struct {
+	Shell string
+}
+
+// ShellInit prints shell snippet which adds cube prompt segment, i.e.
+// active cluster and tunnel state, to the shell prompt.
+func ShellInit(conf ShellInitConfig) error {
+	snippet, err := genShellInit(conf.Shell)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stdout, snippet)
+	return nil
+}
+
+func genShellInit(shell string) (string, error) {
+	switch strings.ToLower(shell) {
+	case "bash":
+		return `__cube_ps1() { cube prompt 2>/dev/null; }
+case "$PS1" in
+  *__cube_ps1*) ;;
+  *) PS1='$(__cube_ps1)'"$PS1" ;;
+esac
+# run: eval "$(cube shell-init bash)"`, nil
+	case "zsh":
+		return `__cube_ps1() { cube prompt 2>/dev/null; }
+setopt PROMPT_SUBST
+case "$PROMPT" in
+  *__cube_ps1*) ;;
+  *) PROMPT='$(__cube_ps1)'"$PROMPT" ;;
+esac
+# run: eval "$(cube shell-init zsh)"`, nil
+	case "fish":
+		return `function __cube_prompt
+    cube prompt 2>/dev/null
+end
+if not functions -q __cube_orig_fish_prompt
+    functions -c fish_prompt __cube_orig_fish_prompt
+    function fish_prompt
+        __cube_prompt
+        __cube_orig_fish_prompt
+    end
+end
+# run: cube shell-init fish | source`, nil
+	default:
+		return "", fmt.Errorf("%w - %v", errUnsupportedShell, shell)
+	}
+}
+
+// Prompt prints prompt segment for current-context, nothing if it's not set.
+func Prompt() error {
+	kc, metas, err := newClient("").LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := kc.Contexts[kc.CurrentContext]; !ok {
+		return nil
+	}
+
+	name := kube.ShortName(metas, kc.CurrentContext)
+	var tunnel string
+	if info, err := kube.ParseContext(kc, metas, kc.CurrentContext); err == nil {
+		tunnel = kube.TunnelDown
+		if base.IsListening(info.LocalPort) {
+			tunnel = kube.TunnelUp
+		}
+	}
+
+	fmt.Fprint(os.Stdout, promptSegment(name, tunnel))
+	return nil
+}
+
+// promptSegment formats prompt segment, e.g. `[182-qa|up] `. Tunnel state
+// is only shown for managed clusters.
+func promptSegment(name, tunnel string) string {
+	if tunnel == "" {
+		return fmt.Sprintf("[%v] ", name)
+	}
+
+	return fmt.Sprintf("[%v|%v] ", name, tunnel)
+}
+
+type ShellConfig struct {
+	Name  string
+	Match string
+	Shell string // shell to spawn, $SHELL if not set
+
+	SSHVia        string
+	TunnelTimeout time.Duration
+}
+
+// Shell spawns a subshell with its own KUBECONFIG holding only given
+// cluster, so different terminals can target different clusters at once.
+// Tunnel is started if it isn't running, and stopped after the shell exits.
+func Shell(conf ShellConfig) error {
+	if ctx := os.Getenv(CubeShellEnv); ctx != "" {
+		return fmt.Errorf("%w - context: %v", errNestedShell, ctx)
+	}
+
+	c := newClient(conf.SSHVia)
+
+	if conf.Name == "" {
+		if !picker.Available() {
+			return errEmptyShellName
+		}
+
+		ctxName, err := pickContext(c, "Shell cluster")
+		if err != nil {
+			return err
+		}
+		conf.Name, conf.Match = ctxName, string(matcher.Exact)
+	}
+
+	kc, metas, err := c.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := matcher.Parse(conf.Match, conf.Name)
+	if err != nil {
+		return err
+	}
+
+	ctxName, err := kube.ResolveContext(kc, metas, m)
+	if err != nil {
+		return err
+	}
+
+	// the shell handles interrupts itself.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	sess, err := openSession(c, kc, ctxName, conf.TunnelTimeout)
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+
+	shell := conf.Shell
+	if shell == "" {
+		shell = defaultShell()
+	}
+
+	log.Info("entering shell, exit to leave", "context", ctxName)
+
+	cmd := sess.Command([]string{shell})
+	cmd.Env = append(cmd.Env, CubeShellEnv+"="+ctxName)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	return toExitError(cmd.Run())
+}
+
+func defaultShell() string {
+	if s := os.Getenv("SHELL"); s != "" {
+		return s
+	}
+
+	return "/bin/sh"
+}
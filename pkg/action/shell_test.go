@@ -0,0 +1,40 @@
+package action
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenShellInit(t *testing.T) {
+	var tests = []struct {
+		shell    string
+		contains []string
+	}{
+		{"bash", []string{"__cube_ps1()", `PS1='$(__cube_ps1)'"$PS1"`}},
+		{"zsh", []string{"setopt PROMPT_SUBST", `PROMPT='$(__cube_ps1)'"$PROMPT"`}},
+		{"fish", []string{"function __cube_prompt", "functions -c fish_prompt __cube_orig_fish_prompt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			snippet, err := genShellInit(tt.shell)
+			assert.Nil(t, err)
+			assert.Contains(t, snippet, "cube prompt")
+			for _, v := range tt.contains {
+				assert.Contains(t, snippet, v)
+			}
+		})
+	}
+
+	_, err := genShellInit("tcsh")
+	assert.True(t, errors.Is(err, errUnsupportedShell))
+}
+
+func TestPromptSegment(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("[172.31.7.182-qa|up] ", promptSegment("172.31.7.182-qa", "up"))
+	assert.Equal("[kind-dev] ", promptSegment("kind-dev", ""))
+}
@@ -0,0 +1,78 @@
+package action
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/log"
+)
+
+type SSHConfigConfig struct {
+	Path  string // ssh config file
+	Write bool   // update managed block in place, otherwise print it
+
+	SSHVia       string
+	RemoteUser   string
+	IdentityFile string
+	IPRanges     []string
+	SocksPort    int
+	LocalForward bool
+}
+
+// SSHConfig generates ssh config entries for jump server and masters of
+// managed clusters, and prints them or updates the managed block of ssh
+// config file in place.
+func SSHConfig(conf SSHConfigConfig) error {
+	metas, err := kube.LoadMetasFrom(newClient(conf.SSHVia).State().MetaPath())
+	if err != nil {
+		return err
+	}
+
+	via := conf.SSHVia
+	if via == "" {
+		via = os.Getenv("SSH_VIA")
+	}
+
+	block, err := kube.GenSSHConfig(metas, kube.SSHConfigOptions{
+		Via:          via,
+		RemoteUser:   conf.RemoteUser,
+		IdentityFile: conf.IdentityFile,
+		IPRanges:     conf.IPRanges,
+		SocksPort:    conf.SocksPort,
+		LocalForward: conf.LocalForward,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !conf.Write {
+		fmt.Fprint(os.Stdout, block)
+		return nil
+	}
+
+	p := base.ExpandPath(conf.Path)
+	content, err := base.ReadFileString(p)
+	if err != nil {
+		return err
+	}
+
+	updated := kube.SSHConfigBlock.Upsert(content, block)
+	if updated == content {
+		log.Info("ssh config is up to date", "path", p)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+
+	if err := base.WriteFileString(p, updated, 0600); err != nil {
+		return err
+	}
+
+	log.Info("ssh config updated", "path", p)
+	return nil
+}
@@ -0,0 +1,144 @@
+package action
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/cube"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/log"
+	"github.com/shohi/cube/pkg/matcher"
+	"github.com/shohi/cube/pkg/picker"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// PreviousContextName refers to the context used before last switch.
+const PreviousContextName = "-"
+
+type UseConfig struct {
+	Name  string
+	Match string
+
+	// Tunnel starts ssh port forwarding if it isn't running.
+	Tunnel bool
+	SSHVia string
+}
+
+// Use sets current-context. If no name given, cluster is picked
+// interactively on terminal, otherwise current-context is printed.
+func Use(conf UseConfig) error {
+	c := newClient(conf.SSHVia)
+
+	if conf.Name == "" && picker.Available() {
+		ctxName, err := pickContext(c, "Use cluster")
+		if err != nil {
+			return err
+		}
+		conf.Name, conf.Match = ctxName, string(matcher.Exact)
+	}
+
+	kc, metas, err := c.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if conf.Name == "" {
+		fmt.Fprintln(os.Stdout, kc.CurrentContext)
+		return nil
+	}
+
+	return switchContext(c, kc, metas, conf)
+}
+
+// switchContext sets current-context to the cluster given in conf, and
+// starts its tunnel if asked.
+func switchContext(c *cube.Client, kc *clientcmdapi.Config, metas kube.Metas, conf UseConfig) error {
+	var ctxName string
+	var err error
+	if conf.Name == PreviousContextName {
+		if ctxName, err = kube.PreviousContext(c.State()); err != nil {
+			return err
+		}
+	} else {
+		m, err := matcher.Parse(conf.Match, conf.Name)
+		if err != nil {
+			return err
+		}
+
+		if ctxName, err = kube.ResolveContext(kc, metas, m); err != nil {
+			return err
+		}
+	}
+
+	if _, err := kube.SwitchContext(c.Store(), c.State(), kc, ctxName); err != nil {
+		return err
+	}
+	log.Info("switched to context", "context", ctxName)
+
+	if !conf.Tunnel {
+		return nil
+	}
+
+	return ensureTunnel(c, kc, metas, ctxName)
+}
+
+// ensureTunnel starts ssh port forwarding for managed cluster by client
+// if it isn't running.
+func ensureTunnel(c *cube.Client, kc *clientcmdapi.Config, metas kube.Metas, ctxName string) error {
+	info, err := kube.ParseContext(kc, metas, ctxName)
+	if err != nil {
+		log.Info("no tunnel for context", "context", ctxName, "reason", err)
+		return nil
+	}
+
+	if base.IsListening(info.LocalPort) {
+		log.Info("tunnel already running", "context", ctxName, "port", info.LocalPort)
+		return nil
+	}
+
+	_, err = c.Forward(cube.ForwardOptions{
+		Name:      ctxName,
+		Match:     string(matcher.Exact),
+		Operation: cube.OpRun,
+	})
+
+	return err
+}
+
+type NsConfig struct {
+	Namespace string
+}
+
+// Ns sets namespace of current-context. If no namespace given, the
+// current one is printed.
+func Ns(conf NsConfig) error {
+	store := newClient("").Store()
+	kc, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	if conf.Namespace == "" {
+		ctx, ok := kc.Contexts[kc.CurrentContext]
+		if !ok {
+			return kube.ErrNoCurrentContext
+		}
+
+		ns := ctx.Namespace
+		if ns == "" {
+			ns = "default"
+		}
+		fmt.Fprintln(os.Stdout, ns)
+		return nil
+	}
+
+	ctxName, err := kube.SetNamespace(store, kc, conf.Namespace)
+	if err != nil {
+		return err
+	}
+
+	log.Info("switched namespace", "context", ctxName, "namespace", conf.Namespace)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package base
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// MarkedBlock is a block of lines managed by cube in a config file owned
+// by user, e.g. ~/.ssh/config, delimited by marker comments.
+type MarkedBlock struct {
+	Begin string
+	End   string
+}
+
+// Wrap wraps body with markers.
+func (b MarkedBlock) Wrap(body string) string {
+	return b.Begin + "\n" + strings.TrimRight(body, "\n") + "\n" + b.End + "\n"
+}
+
+// Find returns start and end offsets of the block in content, -1 if not found.
+func (b MarkedBlock) Find(content string) (int, int) {
+	start := strings.Index(content, b.Begin+"\n")
+	if start < 0 {
+		return -1, -1
+	}
+
+	n := strings.Index(content[start:], b.End)
+	if n < 0 {
+		return -1, -1
+	}
+
+	end := start + n + len(b.End)
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+
+	return start, end
+}
+
+// Upsert replaces the block in content in place, or appends it if not found.
+func (b MarkedBlock) Upsert(content, block string) string {
+	if start, end := b.Find(content); start >= 0 {
+		return content[:start] + block + content[end:]
+	}
+
+	switch {
+	case content == "":
+		return block
+	case strings.HasSuffix(content, "\n\n"):
+		return content + block
+	case strings.HasSuffix(content, "\n"):
+		return content + "\n" + block
+	default:
+		return content + "\n\n" + block
+	}
+}
+
+// Remove removes the block from content, along with the blank line added
+// before it by Upsert if it's at the end.
+func (b MarkedBlock) Remove(content string) string {
+	start, end := b.Find(content)
+	if start < 0 {
+		return content
+	}
+
+	head := content[:start]
+	if end == len(content) && strings.HasSuffix(head, "\n\n") {
+		head = head[:len(head)-1]
+	}
+
+	return head + content[end:]
+}
+
+// ReadFileString reads file content, empty if file doesn't exist.
+func ReadFileString(p string) (string, error) {
+	content, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+
+	return string(content), err
+}
+
+// WriteFileString writes content to file, keeping mode of existing file.
+func WriteFileString(p, content string, perm os.FileMode) error {
+	if info, err := os.Stat(p); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	return ioutil.WriteFile(p, []byte(content), perm)
+}
@@ -0,0 +1,42 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkedBlock(t *testing.T) {
+	b := MarkedBlock{Begin: "# BEGIN test", End: "# END test"}
+	block := b.Wrap("Host a\n  User core\n")
+	assert.Equal(t, "# BEGIN test\nHost a\n  User core\n# END test\n", block)
+
+	var tests = []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"empty", "", block},
+		{"no newline", "Host x", "Host x\n\n" + block},
+		{"newline", "Host x\n", "Host x\n\n" + block},
+		{"replace", "Host x\n\n# BEGIN test\nold\n# END test\nHost y\n", "Host x\n\n" + block + "Host y\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := b.Upsert(tt.content, block)
+			assert.Equal(t, tt.expected, updated)
+
+			// idempotent
+			assert.Equal(t, updated, b.Upsert(updated, block))
+		})
+	}
+
+	assert.Equal(t, "Host x\n\nHost y\n", b.Remove("Host x\n\n"+block+"Host y\n"))
+	assert.Equal(t, "Host x\n", b.Remove("Host x\n"))
+
+	// round trip
+	for _, content := range []string{"", "Host x\n"} {
+		assert.Equal(t, content, b.Remove(b.Upsert(content, block)))
+	}
+}
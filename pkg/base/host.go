@@ -22,6 +22,17 @@ func ExtractHost(remoteAddr string) string {
 	return tokens[len(tokens)-1]
 }
 
+// ExtractUser extracts user info from remoteAddr which is in the format `user@host`.
+// Empty if no user given.
+func ExtractUser(remoteAddr string) string {
+	idx := strings.LastIndex(remoteAddr, "@")
+	if idx < 0 {
+		return ""
+	}
+
+	return remoteAddr[:idx]
+}
+
 // GetHost returns Host part of address, Host or Host:port if port given.
 func GetHost(srvAddr string) string {
 	if !strings.HasPrefix(srvAddr, "http") {
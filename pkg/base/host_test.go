@@ -73,7 +73,7 @@ func TestGetPort(t *testing.T) {
 		port int
 	}{
 		{"hostname-only", "kubernetes", 80},
-		{"hostname-w/o-port", "https://kubernetes", 80},
+		{"hostname-w/o-port", "https://kubernetes", 443},
 		{"hostname-w-port", "https://kubernetes:6443", 6443},
 		{"hostname-w/o-schema", "kubernetes:8080", 8080},
 		{"ip-w-port", "https://172.17.1.1:6443", 6443},
@@ -83,7 +83,7 @@ func TestGetPort(t *testing.T) {
 
 		t.Run(test.name, func(t *testing.T) {
 			assert := assert.New(t)
-			p := GetPort(test.addr)
+			p, _ := GetPort(test.addr)
 			assert.Equal(test.port, p)
 		})
 	}
@@ -2,11 +2,40 @@ package base
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/atrox/homedir"
 )
 
+const (
+	// KubeConfigEnv is the env var holding a list of kubeconfig files.
+	KubeConfigEnv = "KUBECONFIG"
+
+	// StorageFile merges all managed clusters into one kubeconfig file.
+	StorageFile = "file"
+	// StorageSplit writes each managed cluster to its own file under split dir.
+	StorageSplit = "split"
+
+	splitFileExt = ".yaml"
+)
+
+var (
+	// KubeConfigFlag is the kubeconfig file given by `--kubeconfig`.
+	// If set, it takes precedence over KUBECONFIG and the default path.
+	KubeConfigFlag string
+
+	// KubeConfigTarget is the kubeconfig file given by `--kubeconfig-target`.
+	// If set, newly added entries are written to it instead of the default
+	// destination chosen by the loading rules.
+	KubeConfigTarget string
+
+	// StorageMode is how kubeconfig of managed clusters is stored,
+	// either `file` or `split`.
+	StorageMode = StorageFile
+)
+
 // GetLocalKubePath returns local kubeconfig absolute path.
 func GetLocalKubePath() string {
 	p, err := homedir.Expand(LocalKubeConfigPath)
@@ -17,22 +46,93 @@ func GetLocalKubePath() string {
 	return p
 }
 
-// GenLocalCertAuthPath creates local path for remote cert-auth file
-func GenLocalCertAuthPath(remoteAddr string) string {
-	filename := ExtractHost(remoteAddr)
-	return filepath.Join(DefaultCertDir, filename+"-ca.crt")
+// GetLocalKubePaths returns kubeconfig files in loading order, following
+// kubectl's rules, i.e. `--kubeconfig`, then `KUBECONFIG`, then the
+// default path.
+func GetLocalKubePaths() []string {
+	return LocalKubePaths(KubeConfigFlag)
+}
+
+// LocalKubePaths returns kubeconfig files in loading order, i.e. given
+// file if any, then `KUBECONFIG`, then the default path.
+func LocalKubePaths(explicit string) []string {
+	if explicit != "" {
+		return []string{ExpandPath(explicit)}
+	}
+
+	if env := os.Getenv(KubeConfigEnv); env != "" {
+		var ret []string
+		seen := make(map[string]bool)
+		for _, p := range filepath.SplitList(env) {
+			if p == "" || seen[p] {
+				continue
+			}
+			seen[p] = true
+			ret = append(ret, ExpandPath(p))
+		}
+
+		if len(ret) > 0 {
+			return ret
+		}
+	}
+
+	return []string{GetLocalKubePath()}
+}
+
+// GetKubeTargetPath returns the kubeconfig file chosen by `--kubeconfig-target`,
+// or empty if not set.
+func GetKubeTargetPath() string {
+	if KubeConfigTarget == "" {
+		return ""
+	}
+
+	return ExpandPath(KubeConfigTarget)
 }
 
-// GenLocalCertClientPath creates local path for remote client-cert file
-func GenLocalCertClientPath(remoteAddr string) string {
-	filename := ExtractHost(remoteAddr)
+// IsSplitStorage checks whether each managed cluster is stored in its own file.
+func IsSplitStorage() bool {
+	return StorageMode == StorageSplit
+}
+
+// GetSplitDir returns the dir where per-cluster kubeconfig files are stored.
+func GetSplitDir() string {
+	return ExpandPath(SplitKubeConfigDir)
+}
+
+// GenSplitPath creates per-cluster kubeconfig path for given name suffix,
+// that's, `~/.kube/cube.d/$SUFFIX.yaml`.
+func GenSplitPath(nameSuffix string) string {
+	return SplitPath(GetSplitDir(), nameSuffix)
+}
+
+// SplitPath creates per-cluster kubeconfig path in given split dir.
+func SplitPath(dir, nameSuffix string) string {
+	return filepath.Join(dir, nameSuffix+splitFileExt)
+}
 
-	return filepath.Join(DefaultCertDir, filename+"-client.crt")
+// GetSplitPaths returns all per-cluster kubeconfig files in split dir.
+func GetSplitPaths() []string {
+	return SplitPaths(GetSplitDir())
 }
 
-// GenLocalCertClientKeyPath creates local path for remote client-key file
-func GenLocalCertClientKeyPath(remoteAddr string) string {
-	filename := ExtractHost(remoteAddr)
+// SplitPaths returns all per-cluster kubeconfig files in given split dir.
+func SplitPaths(dir string) []string {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+splitFileExt))
+	if err != nil {
+		return nil
+	}
+
+	sort.Strings(matches)
+
+	return matches
+}
+
+// ExpandPath expands leading `~` of path to home dir.
+func ExpandPath(p string) string {
+	ep, err := homedir.Expand(p)
+	if err != nil {
+		panic(fmt.Sprintf("failed to expand path - %v, err: %v", p, err))
+	}
 
-	return filepath.Join(DefaultCertDir, filename+"-client.key")
+	return ep
 }
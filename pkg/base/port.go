@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // GetPort returns port part of address.
@@ -36,6 +37,19 @@ func GetPort(srvAddr string) (port int, notFound bool) {
 	return pVal, false
 }
 
+// IsListening tests whether given port on localhost accepts connections,
+// e.g. a ssh tunnel is running on it.
+func IsListening(port int) bool {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 500*time.Millisecond)
+	if err != nil {
+		return false
+	}
+
+	_ = conn.Close()
+
+	return true
+}
+
 // IsAvailable tests whether given port is available on localhost.
 func IsAvailable(port int) bool {
 	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
@@ -0,0 +1,102 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	LocalKubeConfigPath = "~/.kube/config"
+	SplitKubeConfigDir  = "~/.kube/cube.d"
+
+	ErrFailedCreateCacheDir = errors.New("failed to create cache dir")
+	ErrFailedCreateCertDir  = errors.New("failed to create cert dir")
+	ErrFailedCreateHistory  = errors.New("failed to create history file")
+)
+
+// defaultStateDir is where cube keeps its state, i.e. metadata, certs,
+// caches and history.
+const defaultStateDir = "~/.config/cube"
+
+// stateDir overrides defaultStateDir if set.
+var stateDir string
+
+// StateDir returns dir of cube state. It's resolved on use, so that
+// importing cube has no side effects.
+func StateDir() string {
+	if stateDir != "" {
+		return stateDir
+	}
+
+	return ExpandPath(defaultStateDir)
+}
+
+// SetStateDir sets dir of cube state, empty means the default one.
+func SetStateDir(dir string) {
+	stateDir = dir
+}
+
+// DefaultState returns state under StateDir.
+func DefaultState() State {
+	return State{Dir: StateDir()}
+}
+
+// State is layout of files kept by cube under a state dir.
+type State struct {
+	Dir string
+}
+
+// NewState creates state under given dir, empty means StateDir.
+func NewState(dir string) State {
+	if dir == "" {
+		return DefaultState()
+	}
+
+	return State{Dir: dir}
+}
+
+func (s State) CertDir() string     { return filepath.Join(s.Dir, "cert") }
+func (s State) CacheDir() string    { return filepath.Join(s.Dir, "cache") }
+func (s State) MetaPath() string    { return filepath.Join(s.Dir, "meta.json") }
+func (s State) PrevCtxPath() string { return filepath.Join(s.Dir, "previous-context") }
+func (s State) HistoryPath() string { return filepath.Join(s.Dir, "history") }
+
+// CertAuthPath creates local path for remote cert-auth file.
+func (s State) CertAuthPath(remoteAddr string) string {
+	return filepath.Join(s.CertDir(), ExtractHost(remoteAddr)+"-ca.crt")
+}
+
+// CertClientPath creates local path for remote client-cert file.
+func (s State) CertClientPath(remoteAddr string) string {
+	return filepath.Join(s.CertDir(), ExtractHost(remoteAddr)+"-client.crt")
+}
+
+// CertClientKeyPath creates local path for remote client-key file.
+func (s State) CertClientKeyPath(remoteAddr string) string {
+	return filepath.Join(s.CertDir(), ExtractHost(remoteAddr)+"-client.key")
+}
+
+// Ensure creates state dirs and history file if missing.
+func (s State) Ensure() error {
+	if err := os.MkdirAll(s.CertDir(), os.ModePerm); err != nil {
+		return fmt.Errorf("%v, cause: %v", ErrFailedCreateCertDir, err)
+	}
+
+	if err := os.MkdirAll(s.CacheDir(), os.ModePerm); err != nil {
+		return fmt.Errorf("%v, cause: %v", ErrFailedCreateCacheDir, err)
+	}
+
+	f, err := os.OpenFile(s.HistoryPath(), os.O_RDONLY|os.O_CREATE, 0666)
+	if err != nil {
+		return fmt.Errorf("%v, cause: %v", ErrFailedCreateHistory, err)
+	}
+
+	return f.Close()
+}
+
+// EnsureStateDirs creates dirs and files of default state if missing.
+func EnsureStateDirs() error {
+	return DefaultState().Ensure()
+}
@@ -12,3 +12,13 @@ func FileExists(filename string) (exist, isDir bool) {
 
 	return true, info.IsDir()
 }
+
+// IsTerminal checks if the file is a terminal, e.g. interactive stdin.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
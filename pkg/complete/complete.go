@@ -0,0 +1,114 @@
+// Package complete provides dynamic completion of cluster names and flag
+// values for cube commands.
+package complete
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shohi/cube/pkg/history"
+	"github.com/shohi/cube/pkg/kube"
+)
+
+// Func completes flag value or argument.
+type Func func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// Clusters completes short names of all clusters.
+func Clusters(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return clusterNames(false, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// ManagedClusters completes short names of clusters managed by cube.
+func ManagedClusters(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return clusterNames(true, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// FirstArg completes only the first positional argument with fn.
+func FirstArg(fn Func) Func {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return fn(cmd, args, toComplete)
+	}
+}
+
+// Values completes fixed values, e.g. operations of forward.
+func Values(values ...string) Func {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return filterPrefix(values, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// RemoteIPs completes remote hosts of managed clusters and previously used
+// `--remote-ip` values.
+func RemoteIPs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return usedValues("remote-ip", func(m *kube.Meta) string { return m.RemoteHost }, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// RemoteUsers completes remote users of managed clusters and previously
+// used `--remote-user` values.
+func RemoteUsers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return usedValues("remote-user", func(m *kube.Meta) string { return m.RemoteUser }, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// usedValues collects values of flag from history, most recent first,
+// followed by those recorded in metadata.
+func usedValues(flag string, field func(m *kube.Meta) string, toComplete string) []string {
+	values, _ := history.FlagValues(flag)
+
+	metas, _ := kube.LoadMetas()
+	var recorded []string
+	for _, m := range metas {
+		recorded = append(recorded, field(m))
+	}
+	sort.Strings(recorded)
+
+	return filterPrefix(unique(append(values, recorded...)), toComplete)
+}
+
+func clusterNames(managedOnly bool, toComplete string) []string {
+	infos, _, err := kube.ListAllClusters()
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, v := range infos {
+		if managedOnly && v.Type != kube.TypeCubeTunnel {
+			continue
+		}
+		names = append(names, v.Name)
+	}
+
+	return filterPrefix(names, toComplete)
+}
+
+func filterPrefix(values []string, prefix string) []string {
+	var ret []string
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
+
+// unique removes empty and duplicated values, keeping the first occurrence.
+func unique(values []string) []string {
+	seen := make(map[string]bool)
+	var ret []string
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		ret = append(ret, v)
+	}
+
+	return ret
+}
@@ -0,0 +1,34 @@
+package complete
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValues(t *testing.T) {
+	assert := assert.New(t)
+
+	fn := Values("print", "run", "stop")
+	values, directive := fn(nil, nil, "")
+	assert.Equal([]string{"print", "run", "stop"}, values)
+	assert.Equal(cobra.ShellCompDirectiveNoFileComp, directive)
+
+	values, _ = fn(nil, nil, "r")
+	assert.Equal([]string{"run"}, values)
+}
+
+func TestFirstArg(t *testing.T) {
+	fn := FirstArg(Values("qa"))
+
+	values, _ := fn(nil, nil, "")
+	assert.Equal(t, []string{"qa"}, values)
+
+	values, _ = fn(nil, []string{"qa"}, "")
+	assert.Nil(t, values)
+}
+
+func TestUnique(t *testing.T) {
+	assert.Equal(t, []string{"b", "a"}, unique([]string{"b", "", "a", "b"}))
+}
@@ -0,0 +1,106 @@
+package cube
+
+import (
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+)
+
+// AddOptions describe the remote cluster to add.
+type AddOptions struct {
+	RemoteUser string // user on master, core if not set
+	RemoteIP   string // private ip of master
+
+	LocalPort  int // local forwarding port. If not set, the lowest available in PortRange is used
+	NameSuffix string
+
+	DryRun bool // merge without saving
+	Force  bool
+
+	Naming     kube.Naming // templates of local names. If not set, kube.DefaultNaming is used
+	OnConflict string      // fail/replace/rename/skip, fail if not set
+	PortRange  string      // e.g. 7001-7100. If not set, kube.DefaultPortRange is used
+	Labels     string      // e.g. env=prod,region=eu
+}
+
+// AddResult holds the merged kubeconfig and how the cluster is merged.
+type AddResult struct {
+	Config        *clientcmdapi.Config `json:"-"`
+	Summary       kube.MergeSummary    `json:"summary"`
+	LocalPort     int                  `json:"localPort"`
+	RemoteAPIAddr string               `json:"remoteAPIAddr"`
+	SSHForward    string               `json:"sshForward"`
+	Saved         bool                 `json:"saved"`
+}
+
+// Add fetches kubeconfig of remote cluster and merges it into local
+// kubeconfig. Nothing is saved with DryRun.
+func (c *Client) Add(opts AddOptions) (*AddResult, error) {
+	if opts.RemoteUser == "" {
+		opts.RemoteUser = "core"
+	}
+	if opts.OnConflict == "" {
+		opts.OnConflict = string(kube.ConflictFail)
+	}
+
+	onConflict, err := kube.ParseConflictStrategy(opts.OnConflict)
+	if err != nil {
+		return nil, err
+	}
+
+	var portRange kube.PortRange
+	if opts.PortRange != "" {
+		if portRange, err = kube.ParsePortRange(opts.PortRange); err != nil {
+			return nil, err
+		}
+	}
+
+	labels, err := kube.ParseLabels(opts.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.state.Ensure(); err != nil {
+		return nil, err
+	}
+
+	m := kube.NewMerger(kube.MergeOptions{
+		RemoteAddr: base.SshHost(opts.RemoteUser, opts.RemoteIP),
+		NameSuffix: opts.NameSuffix,
+		LocalPort:  opts.LocalPort,
+		Force:      opts.Force,
+		Naming:     opts.Naming,
+		OnConflict: onConflict,
+		PortRange:  portRange,
+		Labels:     labels,
+		Transport:  c.opts.Transport,
+		Store:      c.store,
+		StateDir:   c.state.Dir,
+		SSHVia:     c.opts.SSHVia,
+		Stderr:     c.opts.Stderr,
+		Logger:     c.log,
+	})
+	if err := m.Merge(); err != nil {
+		return nil, err
+	}
+
+	res := &AddResult{
+		Config:        m.Result(),
+		Summary:       m.Summary(),
+		LocalPort:     m.LocalPort(),
+		RemoteAPIAddr: m.RemoteAPIAddr(),
+	}
+	res.SSHForward = kube.GetPortForwardingCmd(res.LocalPort, res.RemoteAPIAddr, c.opts.SSHVia)
+
+	if opts.DryRun {
+		return res, nil
+	}
+
+	if err := m.Save(); err != nil {
+		return nil, err
+	}
+	res.Saved = true
+
+	return res, nil
+}
@@ -0,0 +1,146 @@
+// Package cube is the Go API of cube, for embedding it in other tools.
+//
+//	c := cube.New(cube.Options{KubeConfig: "/path/to/kubeconfig"})
+//	res, err := c.Add(cube.AddOptions{RemoteIP: "172.31.7.182", NameSuffix: "qa"})
+//
+// Methods return structured results instead of printing. Importing the
+// package has no side effects, state dirs are created when needed.
+package cube
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/log"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+var (
+	ErrEmptyName             = errors.New("cube: empty cluster name and selector")
+	ErrClusterNotFound       = errors.New("cube: cluster not found")
+	ErrMultipleClustersFound = errors.New("cube: multiple clusters found")
+	ErrSSHViaNotSet          = kube.ErrSSHViaNotSet
+)
+
+// Options configure a Client. Zero value uses the same defaults as the
+// cube command.
+type Options struct {
+	// KubeConfig is the kubeconfig file. If not set, KUBECONFIG env or
+	// ~/.kube/config is used.
+	KubeConfig string
+
+	// KubeConfigTarget is the kubeconfig file new entries are written to.
+	// If not set, the first existing kubeconfig file is used.
+	KubeConfigTarget string
+
+	// Storage is storage mode for managed clusters, file or split.
+	Storage string
+
+	// StateDir is where metadata, certs and caches are kept. If not set,
+	// ~/.config/cube is used.
+	StateDir string
+
+	// SSHVia is the ssh jump server, e.g. user@jump. If not set, SSH_VIA
+	// env is used.
+	SSHVia string
+
+	// Transport fetches remote kubeconfig and certs. If not set, scp is
+	// used, with its output written to Stdout and Stderr.
+	Transport kube.Transport
+
+	// Stdout receives output of commands run by cube, e.g. scp and ssh.
+	// Stderr also receives logs if Logger not set. Both are discarded if
+	// not set.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Logger receives logs. If not set, logs of info level and above are
+	// written to Stderr as text.
+	Logger *log.Logger
+}
+
+// Client runs cube operations with given options. Clients don't share
+// state, so that clients with different options can be used concurrently.
+type Client struct {
+	opts  Options
+	store *kube.Store
+	state base.State
+	log   *log.Logger
+}
+
+// New creates a Client.
+func New(opts Options) *Client {
+	if opts.Storage == "" {
+		opts.Storage = base.StorageFile
+	}
+	if opts.StateDir == "" {
+		opts.StateDir = base.StateDir()
+	}
+	if opts.SSHVia == "" {
+		opts.SSHVia = os.Getenv("SSH_VIA")
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = ioutil.Discard
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = ioutil.Discard
+	}
+	if opts.Transport == nil {
+		opts.Transport = kube.SCPTransport{Stdout: opts.Stdout, Stderr: opts.Stderr}
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = log.New(opts.Stderr, log.LevelInfo, log.FormatText)
+	}
+
+	return &Client{
+		opts: opts,
+		store: kube.NewStoreWith(kube.StoreOptions{
+			KubeConfig: opts.KubeConfig,
+			Target:     opts.KubeConfigTarget,
+			Split:      opts.Storage == base.StorageSplit,
+		}),
+		state: base.NewState(opts.StateDir),
+		log:   opts.Logger,
+	}
+}
+
+// Store returns the local kubeconfig store of the client.
+func (c *Client) Store() *kube.Store {
+	return c.store
+}
+
+// State returns where the client keeps metadata, certs and caches.
+func (c *Client) State() base.State {
+	return c.state
+}
+
+// LoadConfig reads local kubeconfig and metadata of the client.
+func (c *Client) LoadConfig() (*clientcmdapi.Config, kube.Metas, error) {
+	kc, err := c.store.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metas, err := kube.LoadMetasFrom(c.state.MetaPath())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return kc, metas, nil
+}
+
+// parseMatcher parses matcher of name, using defaultMode if mode not set.
+func parseMatcher(mode string, defaultMode matcher.Mode, name string) (matcher.Matcher, error) {
+	if mode == "" {
+		return matcher.New(defaultMode, name)
+	}
+
+	return matcher.Parse(mode, name)
+}
@@ -0,0 +1,235 @@
+package cube
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+const remoteKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://172.31.7.182:6443
+  name: kubernetes
+contexts:
+- context:
+    cluster: kubernetes
+    user: admin
+  name: admin@kubernetes
+current-context: admin@kubernetes
+users:
+- name: admin
+  user:
+    token: abc
+`
+
+const localKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://10.0.0.1:6443
+  name: local
+contexts:
+- context:
+    cluster: local
+    user: local
+  name: dev
+current-context: dev
+users:
+- name: local
+  user:
+    token: def
+`
+
+// fakeTransport serves remote kubeconfig from memory.
+type fakeTransport struct {
+	fetched []string
+}
+
+func (t *fakeTransport) Fetch(remoteAddr, remotePath, localPath string) error {
+	t.fetched = append(t.fetched, remoteAddr+":"+remotePath)
+	if err := os.MkdirAll(filepath.Dir(localPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(localPath, []byte(remoteKubeConfig), 0600)
+}
+
+func newTestClient(t *testing.T) (*Client, *fakeTransport, string) {
+	dir, err := ioutil.TempDir("", "cube-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kubeConfig := filepath.Join(dir, "config")
+	if err := ioutil.WriteFile(kubeConfig, []byte(localKubeConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := &fakeTransport{}
+	c := New(Options{
+		KubeConfig: kubeConfig,
+		StateDir:   filepath.Join(dir, "state"),
+		SSHVia:     "user@jump",
+		Transport:  tr,
+	})
+
+	return c, tr, dir
+}
+
+func TestNew_defaults(t *testing.T) {
+	assert := assert.New(t)
+
+	c := New(Options{})
+	assert.Equal(base.StorageFile, c.opts.Storage)
+	assert.Equal(base.StateDir(), c.opts.StateDir)
+	assert.Equal(ioutil.Discard, c.opts.Stdout)
+	assert.Equal(ioutil.Discard, c.opts.Stderr)
+	assert.NotNil(c.opts.Transport)
+}
+
+func TestClient_isolated(t *testing.T) {
+	assert := assert.New(t)
+
+	kubeConfig, stateDir := base.KubeConfigFlag, base.StateDir()
+
+	// clients with different options run concurrently, without touching
+	// global settings.
+	var wg sync.WaitGroup
+	clients := make([]*Client, 2)
+	errs := make([]error, 2)
+	for k := range clients {
+		c, _, dir := newTestClient(t)
+		defer os.RemoveAll(dir)
+
+		clients[k] = c
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			_, errs[k] = clients[k].Add(AddOptions{RemoteIP: "172.31.7.182", NameSuffix: "qa", LocalPort: 7001})
+		}(k)
+	}
+	wg.Wait()
+
+	for k, c := range clients {
+		assert.Nil(errs[k])
+
+		l, err := c.List(ListOptions{Managed: true})
+		assert.Nil(err)
+		assert.Equal(1, len(l.Clusters))
+	}
+
+	assert.Equal(kubeConfig, base.KubeConfigFlag)
+	assert.Equal(stateDir, base.StateDir())
+}
+
+func TestClient_AddListDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	c, tr, dir := newTestClient(t)
+	defer os.RemoveAll(dir)
+
+	// dry run leaves kubeconfig untouched.
+	res, err := c.Add(AddOptions{RemoteIP: "172.31.7.182", NameSuffix: "qa", LocalPort: 7001, DryRun: true})
+	assert.Nil(err)
+	assert.False(res.Saved)
+	assert.Equal([]string{"core@172.31.7.182:~/.kube/config"}, tr.fetched)
+	assert.Equal("ssh -fN -L 7001:172.31.7.182:6443 user@jump", res.SSHForward)
+
+	l, err := c.List(ListOptions{})
+	assert.Nil(err)
+	assert.Equal(1, len(l.Clusters))
+
+	res, err = c.Add(AddOptions{RemoteIP: "172.31.7.182", NameSuffix: "qa", LocalPort: 7001})
+	assert.Nil(err)
+	assert.True(res.Saved)
+	assert.Equal(7001, res.LocalPort)
+
+	l, err = c.List(ListOptions{Managed: true})
+	assert.Nil(err)
+	if assert.Equal(1, len(l.Clusters)) {
+		assert.Equal(res.Summary.Context, l.Clusters[0].Context)
+	}
+
+	p, err := c.PlanDelete(DeleteOptions{Name: res.Summary.Context})
+	assert.Nil(err)
+	assert.Equal([]string{res.Summary.Context}, p.Plan.Contexts)
+
+	deleted, err := p.Apply()
+	assert.Nil(err)
+	assert.Equal([]string{res.Summary.Context}, deleted)
+
+	l, err = c.List(ListOptions{})
+	assert.Nil(err)
+	assert.Equal(1, len(l.Clusters))
+}
+
+func TestClient_Forward(t *testing.T) {
+	assert := assert.New(t)
+
+	c, _, dir := newTestClient(t)
+	defer os.RemoveAll(dir)
+
+	_, err := c.Forward(ForwardOptions{})
+	assert.Equal(ErrEmptyName, err)
+
+	_, err = c.Add(AddOptions{RemoteIP: "172.31.7.182", NameSuffix: "qa", LocalPort: 7001})
+	assert.Nil(err)
+
+	_, err = c.Forward(ForwardOptions{Name: "nonexistent"})
+	assert.Equal(ErrClusterNotFound, err)
+
+	res, err := c.Forward(ForwardOptions{Name: "qa"})
+	assert.Nil(err)
+	if assert.Equal(1, len(res.Forwardings)) {
+		assert.Equal("ssh -fN -L 7001:172.31.7.182:6443 user@jump", res.Forwardings[0].Command)
+		assert.Equal(StatusPrinted, res.Forwardings[0].Status)
+	}
+}
+
+func TestClient_ForwardTunnel(t *testing.T) {
+	assert := assert.New(t)
+
+	c, _, dir := newTestClient(t)
+	defer os.RemoveAll(dir)
+
+	// forwarding already running is left as is.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	add, err := c.Add(AddOptions{RemoteIP: "172.31.7.182", NameSuffix: "qa", LocalPort: port})
+	assert.Nil(err)
+
+	names, err := c.Select(ForwardOptions{Name: "qa|dev"})
+	assert.Nil(err)
+	assert.Equal([]string{"dev", add.Summary.Context}, names)
+
+	res, err := c.Forward(ForwardOptions{Context: add.Summary.Context, Operation: OpTunnel})
+	assert.Nil(err)
+	if assert.Equal(1, len(res.Forwardings)) {
+		assert.Equal(StatusRunning, res.Forwardings[0].Status)
+	}
+	res.Close()
+
+	// unmanaged cluster isn't forwarded.
+	res, err = c.Forward(ForwardOptions{Context: "dev", Operation: OpTunnel})
+	assert.Nil(err)
+	if assert.Equal(1, len(res.Forwardings)) {
+		assert.Equal(StatusDirect, res.Forwardings[0].Status)
+	}
+
+	_, err = c.Forward(ForwardOptions{Context: "nonexistent", Operation: OpTunnel})
+	assert.Equal(ErrClusterNotFound, err)
+}
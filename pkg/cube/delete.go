@@ -0,0 +1,76 @@
+package cube
+
+import (
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+// DeleteOptions select clusters to delete, by name or label selector.
+type DeleteOptions struct {
+	Name     string
+	Selector string // label selector, e.g. env=prod
+	Match    string // how name is matched, exact if not set
+	All      bool   // delete all matched, instead of failing on multiple matches
+
+	AllowCurrent bool // allow to delete current-context
+}
+
+// DeletePlan is what is to be deleted. Nothing is changed until Apply.
+type DeletePlan struct {
+	Plan   kube.PurgePlan       `json:"plan"`
+	Config *clientcmdapi.Config `json:"-"` // kubeconfig after deletion
+
+	purger kube.Purger
+}
+
+// Apply saves kubeconfig after deletion, and returns deleted contexts.
+func (p *DeletePlan) Apply() ([]string, error) {
+	if err := p.purger.Save(); err != nil {
+		return nil, err
+	}
+
+	return p.purger.Deleted(), nil
+}
+
+// PlanDelete plans deletion of selected clusters, e.g. to confirm before
+// Apply.
+func (c *Client) PlanDelete(opts DeleteOptions) (*DeletePlan, error) {
+	mode := matcher.Exact
+	if opts.Match != "" {
+		var err error
+		if mode, err = matcher.ParseMode(opts.Match); err != nil {
+			return nil, err
+		}
+	}
+
+	p := kube.NewPurger(kube.PurgeOptions{
+		Name:         opts.Name,
+		Selector:     opts.Selector,
+		All:          opts.All,
+		Match:        mode,
+		AllowCurrent: opts.AllowCurrent,
+		Store:        c.store,
+		StateDir:     c.state.Dir,
+	})
+	if err := p.Purge(); err != nil {
+		return nil, err
+	}
+
+	return &DeletePlan{
+		Plan:   p.Plan(),
+		Config: p.Result(),
+		purger: p,
+	}, nil
+}
+
+// Delete deletes selected clusters, and returns deleted contexts.
+func (c *Client) Delete(opts DeleteOptions) ([]string, error) {
+	p, err := c.PlanDelete(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Apply()
+}
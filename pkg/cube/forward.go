@@ -0,0 +1,351 @@
+package cube
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+var errProcessNotFound = errors.New("process not found")
+
+// Operation is what to do with ssh port forwarding.
+type Operation string
+
+const (
+	OpPrint Operation = "print"
+	OpRun   Operation = "run"
+	OpStop  Operation = "stop"
+
+	// OpTunnel starts ssh port forwarding in foreground, owned by caller
+	// and stopped by ForwardResult.Close. Forwardings already running are
+	// left as is.
+	OpTunnel Operation = "tunnel"
+)
+
+// DefaultTunnelTimeout is how long OpTunnel waits for forwarding to be up.
+const DefaultTunnelTimeout = 15 * time.Second
+
+// ParseOperation parses forwarding operation, unknown means print.
+func ParseOperation(s string) Operation {
+	switch op := Operation(strings.ToLower(s)); op {
+	case OpRun, OpStop:
+		return op
+	default:
+		return OpPrint
+	}
+}
+
+// Status of forwarding after operation.
+const (
+	StatusPrinted  = "printed"
+	StatusStarted  = "started"
+	StatusStopped  = "stopped"
+	StatusNotFound = "not-found" // no forwarding process to stop
+	StatusRunning  = "running"   // forwarding already running
+	StatusDirect   = "direct"    // cluster not forwarded by cube, e.g. unmanaged one
+)
+
+// ForwardOptions select clusters to forward, by name or label selector.
+type ForwardOptions struct {
+	Name     string
+	Selector string // label selector, e.g. env=prod
+	Match    string // how name is matched, regex if not set
+	Exclude  string // regex of names to exclude
+
+	// Context selects the context with exactly this name, instead of
+	// Name and Selector.
+	Context string
+
+	Operation Operation // print if not set
+
+	// CertWindow is the window to warn expiring certs before running forwarding.
+	CertWindow string
+
+	// TunnelTimeout is how long OpTunnel waits for forwarding to be up.
+	// If not set, DefaultTunnelTimeout is used.
+	TunnelTimeout time.Duration
+}
+
+// Forwarding is ssh port forwarding of a context.
+type Forwarding struct {
+	Context string `json:"context"`
+	Command string `json:"command,omitempty"`
+	Status  string `json:"status"`
+
+	tunnel *kube.Tunnel
+}
+
+// ForwardResult holds forwardings of selected contexts.
+type ForwardResult struct {
+	Forwardings []Forwarding `json:"forwardings"`
+}
+
+// Close stops forwardings started by OpTunnel.
+func (r *ForwardResult) Close() {
+	for _, f := range r.Forwardings {
+		if f.tunnel != nil {
+			_ = f.tunnel.Stop()
+		}
+	}
+}
+
+// Select returns names of contexts selected by options, sorted.
+func (c *Client) Select(opts ForwardOptions) ([]string, error) {
+	_, _, names, err := c.selectContexts(opts)
+
+	return names, err
+}
+
+// selectContexts loads local kubeconfig and metadata, and returns names of
+// contexts selected by options, sorted.
+func (c *Client) selectContexts(opts ForwardOptions) (*clientcmdapi.Config, kube.Metas, []string, error) {
+	if opts.Context != "" {
+		kc, metas, err := c.LoadConfig()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if _, ok := kc.Contexts[opts.Context]; !ok {
+			return nil, nil, nil, ErrClusterNotFound
+		}
+
+		return kc, metas, []string{opts.Context}, nil
+	}
+
+	if opts.Name == "" && opts.Selector == "" {
+		return nil, nil, nil, ErrEmptyName
+	}
+
+	sel, err := kube.ParseSelector(opts.Selector)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	include, err := parseMatcher(opts.Match, matcher.Regex, opts.Name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	exclude, err := matcher.NewExclude(opts.Exclude)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	kc, metas, err := c.LoadConfig()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ctxs := kube.SelectContexts(kube.FindContexts(kc, metas, include, exclude), metas, sel)
+	if len(ctxs) == 0 {
+		return nil, nil, nil, ErrClusterNotFound
+	}
+
+	names := make([]string, 0, len(ctxs))
+	for k := range ctxs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	return kc, metas, names, nil
+}
+
+// Forward prints, runs or stops ssh port forwarding of selected clusters.
+// Only one cluster can be selected to run. With OpTunnel, forwardings are
+// started for all selected clusters, and clusters which can't be forwarded
+// are left direct.
+func (c *Client) Forward(opts ForwardOptions) (*ForwardResult, error) {
+	if opts.Operation == "" {
+		opts.Operation = OpPrint
+	}
+	if opts.TunnelTimeout == 0 {
+		opts.TunnelTimeout = DefaultTunnelTimeout
+	}
+
+	kc, metas, names, err := c.selectContexts(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Operation == OpTunnel {
+		return c.openTunnels(kc, metas, names, opts.TunnelTimeout)
+	}
+
+	if c.opts.SSHVia == "" {
+		return nil, ErrSSHViaNotSet
+	}
+
+	if opts.Operation == OpRun && len(names) > 1 {
+		return nil, ErrMultipleClustersFound
+	}
+
+	if opts.Operation == OpRun {
+		c.warnHostMapping()
+		if err := c.warnExpiringCerts(kc, names, opts.CertWindow); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &ForwardResult{}
+	for _, k := range names {
+		info, err := kube.ParseContextVia(kc, metas, k, c.opts.SSHVia)
+		if err != nil {
+			return nil, err
+		}
+
+		status, err := c.doSSHForwarding(opts.Operation, k, info.SSHForward)
+		if err != nil {
+			return nil, err
+		}
+		res.Forwardings = append(res.Forwardings, Forwarding{Context: k, Command: info.SSHForward, Status: status})
+	}
+
+	return res, nil
+}
+
+// openTunnels starts forwardings owned by caller for given contexts, unless
+// they're running. All started ones are stopped on error.
+func (c *Client) openTunnels(kc *clientcmdapi.Config, metas kube.Metas, names []string, timeout time.Duration) (*ForwardResult, error) {
+	res := &ForwardResult{}
+	for _, k := range names {
+		f, err := c.openTunnel(kc, metas, k, timeout)
+		if err != nil {
+			res.Close()
+			return nil, err
+		}
+		res.Forwardings = append(res.Forwardings, f)
+	}
+
+	return res, nil
+}
+
+func (c *Client) openTunnel(kc *clientcmdapi.Config, metas kube.Metas, ctxName string, timeout time.Duration) (Forwarding, error) {
+	info, err := kube.ParseContextVia(kc, metas, ctxName, c.opts.SSHVia)
+	if err != nil {
+		return Forwarding{Context: ctxName, Status: StatusDirect}, nil
+	}
+
+	f := Forwarding{
+		Context: ctxName,
+		Command: strings.Join(kube.GetTunnelCmdArgs(info.LocalPort, info.RemoteAPIAddr, c.opts.SSHVia), " "),
+		Status:  StatusRunning,
+	}
+	if base.IsListening(info.LocalPort) {
+		return f, nil
+	}
+
+	if c.opts.SSHVia == "" {
+		return f, ErrSSHViaNotSet
+	}
+
+	c.log.Debug("start ssh tunnel", "context", ctxName, "port", info.LocalPort, "remote", info.RemoteAPIAddr)
+	if f.tunnel, err = kube.StartTunnel(info.LocalPort, info.RemoteAPIAddr, c.opts.SSHVia, timeout, c.opts.Stderr); err != nil {
+		return f, err
+	}
+	f.Status = StatusStarted
+
+	return f, nil
+}
+
+func (c *Client) doSSHForwarding(op Operation, ctxName, forwardCmd string) (string, error) {
+	switch op {
+	case OpRun:
+		c.log.Debug("start ssh local port forwarding", "context", ctxName, "cmd", forwardCmd)
+		if err := startPortForwarding(forwardCmd, c.opts.Stdout, c.opts.Stderr); err != nil {
+			return "", err
+		}
+		c.log.Info("start ssh local port forwarding successfully", "context", ctxName)
+		return StatusStarted, nil
+	case OpStop:
+		c.log.Debug("stop ssh local port forwarding", "context", ctxName, "cmd", forwardCmd)
+		err := stopPortForwarding(forwardCmd)
+		if err == errProcessNotFound {
+			c.log.Warn("ssh local port forwarding process not found", "context", ctxName)
+			return StatusNotFound, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		c.log.Info("stop ssh local port forwarding successfully", "context", ctxName)
+		return StatusStopped, nil
+	default:
+		return StatusPrinted, nil
+	}
+}
+
+func startPortForwarding(cmdStr string, stdout, stderr io.Writer) error {
+	cmdArgs := strings.Fields(cmdStr)
+
+	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return cmd.Run()
+}
+
+func stopPortForwarding(cmdStr string) error {
+	cmdArgs := strings.Fields(cmdStr)
+	formalCmd := strings.Join(cmdArgs, " ")
+
+	var pfProcess *process.Process
+	lst, err := process.Processes()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range lst {
+		cli, err := p.Cmdline()
+		if err != nil {
+			return err
+		}
+		if strings.Contains(cli, formalCmd) {
+			pfProcess = p
+		}
+	}
+
+	if pfProcess == nil {
+		return errProcessNotFound
+	}
+
+	return pfProcess.Kill()
+}
+
+// warnHostMapping warns if `kubernetes` doesn't resolve to loopback.
+func (c *Client) warnHostMapping() {
+	if _, err := kube.CheckHostMapping(); err != nil {
+		c.log.Warn("host not mapped to loopback, run: cube hosts install --sudo", "err", err)
+	}
+}
+
+// warnExpiringCerts warns certs which have expired or will expire within window.
+func (c *Client) warnExpiringCerts(kc *clientcmdapi.Config, names []string, window string) error {
+	if window == "" {
+		window = kube.DefaultCertWindowString()
+	}
+
+	w, err := kube.ParseCertWindow(window)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range names {
+		for _, info := range kube.ContextCerts(kc, k, w) {
+			switch {
+			case info.Expired:
+				c.log.Warn("cert expired", "context", k, "kind", info.Kind, "notAfter", info.NotAfter)
+			case info.Expiring:
+				c.log.Warn("cert expiring", "context", k, "kind", info.Kind, "notAfter", info.NotAfter)
+			}
+		}
+	}
+
+	return nil
+}
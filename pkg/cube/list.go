@@ -0,0 +1,46 @@
+package cube
+
+import (
+	"github.com/shohi/cube/pkg/kube"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+// ListOptions select clusters to list. Zero value lists all.
+type ListOptions struct {
+	Name     string // name filter
+	Match    string // how name is matched, regex if not set
+	Selector string // label selector, e.g. env=prod
+
+	Managed   bool // only clusters managed by cube
+	Unmanaged bool // only clusters not managed by cube
+}
+
+// ListResult holds listed clusters, sorted by name, and contexts which
+// can't be listed.
+type ListResult struct {
+	Clusters kube.ClusterInfos `json:"clusters"`
+	Warnings kube.ListWarnings `json:"warnings,omitempty"`
+}
+
+// List lists clusters in kubeconfig, both managed by cube and not.
+func (c *Client) List(opts ListOptions) (*ListResult, error) {
+	sel, err := kube.ParseSelector(opts.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := parseMatcher(opts.Match, matcher.Regex, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, warnings, err := kube.ListClusters(c.store, c.state, c.opts.SSHVia)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListResult{
+		Clusters: kube.FilterClusters(infos, kube.ByName(m), kube.ByManaged(opts.Managed, opts.Unmanaged), kube.BySelector(sel)),
+		Warnings: warnings,
+	}, nil
+}
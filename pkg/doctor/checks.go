@@ -0,0 +1,497 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+)
+
+const (
+	sshConfigPath  = "~/.ssh/config"
+	bastionTimeout = 3 * time.Second
+)
+
+func checkBinary(name string) Check {
+	return func(_ bool) Result {
+		title := "binary " + name
+		p, err := exec.LookPath(name)
+		if err != nil {
+			return fail(title, fmt.Sprintf("%v not found in PATH", name),
+				fmt.Sprintf("install openssh client which provides %v", name))
+		}
+
+		return pass(title, p)
+	}
+}
+
+func checkSSHConfig(_ bool) Result {
+	const title = "ssh config"
+
+	p, _ := homedir.Expand(sshConfigPath)
+	content, err := ioutil.ReadFile(p)
+	if err != nil {
+		return warn(title, fmt.Sprintf("failed to read %v, err: %v", p, err),
+			"add Host rules with ProxyCommand for remote master ip range, see README")
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToLower(fields[0]) {
+		case "proxycommand", "proxyjump":
+			return pass(title, fmt.Sprintf("%v found in %v", fields[0], p))
+		}
+	}
+
+	return warn(title, fmt.Sprintf("no ProxyCommand/ProxyJump in %v", p),
+		"add Host rules with ProxyCommand for remote master ip range, see README")
+}
+
+func checkSSHVia(_ bool) Result {
+	const title = "SSH_VIA"
+
+	sv := os.Getenv("SSH_VIA")
+	if sv == "" {
+		return fail(title, "SSH_VIA env not set",
+			"export SSH_VIA=<user>@<public-ip>, or pass --ssh-via to forward")
+	}
+
+	return pass(title, sv)
+}
+
+func checkBastion(_ bool) Result {
+	const title = "bastion"
+
+	sv := strings.Fields(os.Getenv("SSH_VIA"))
+	if len(sv) == 0 {
+		return warn(title, "skipped, SSH_VIA env not set", "")
+	}
+
+	host := base.ExtractHost(sv[0])
+	addr := net.JoinHostPort(host, "22")
+	conn, err := net.DialTimeout("tcp", addr, bastionTimeout)
+	if err != nil {
+		return warn(title, fmt.Sprintf("%v unreachable, err: %v", addr, err),
+			"check network/VPN, or whether SSH_VIA is an alias in ssh config")
+	}
+	_ = conn.Close()
+
+	return pass(title, addr+" reachable")
+}
+
+func checkHostsEntry(_ bool) Result {
+	title := "hosts entry"
+
+	addrs, err := kube.CheckHostMapping()
+	if errors.Is(err, kube.ErrHostNotResolved) {
+		return fail(title, fmt.Sprintf("%v not resolved", kube.DefaultHost), "cube hosts install --sudo")
+	}
+	if err != nil {
+		return fail(title, fmt.Sprintf("%v resolves to %v, not loopback", kube.DefaultHost, addrs),
+			fmt.Sprintf("map %v to 127.0.0.1 in %v, see cube hosts check", kube.DefaultHost, kube.HostsFile()))
+	}
+
+	return pass(title, fmt.Sprintf("%v => %v", kube.DefaultHost, addrs))
+}
+
+func checkStateDirs(fix bool) Result {
+	const title = "state dirs"
+
+	state := base.DefaultState()
+
+	var missing []string
+	for _, d := range []string{state.CacheDir(), state.CertDir()} {
+		if exist, isDir := base.FileExists(d); !exist || !isDir {
+			missing = append(missing, d)
+		}
+	}
+
+	if len(missing) == 0 {
+		return pass(title, fmt.Sprintf("%v, %v", state.CacheDir(), state.CertDir()))
+	}
+
+	r := fail(title, fmt.Sprintf("missing %v", missing), "mkdir -p "+strings.Join(missing, " "))
+	if fix {
+		r.Fixed = true
+		for _, d := range missing {
+			if err := os.MkdirAll(d, os.ModePerm); err != nil {
+				r.Fixed = false
+			}
+		}
+	}
+
+	return r
+}
+
+func checkHistoryFile(fix bool) Result {
+	const title = "history file"
+
+	p := base.DefaultState().HistoryPath()
+	if exist, isDir := base.FileExists(p); exist && !isDir {
+		return pass(title, p)
+	}
+
+	r := warn(title, fmt.Sprintf("missing %v", p), "touch "+p)
+	if fix {
+		if f, err := os.OpenFile(p, os.O_RDONLY|os.O_CREATE, 0666); err == nil {
+			_ = f.Close()
+			r.Fixed = true
+		}
+	}
+
+	return r
+}
+
+func checkMetaFile(_ bool) Result {
+	const title = "metadata"
+
+	p := base.DefaultState().MetaPath()
+	metas, err := kube.LoadMetasFrom(p)
+	if err != nil {
+		return fail(title, fmt.Sprintf("failed to parse %v, err: %v", p, err),
+			"fix or remove "+p+", then run `cube migrate` to rebuild it")
+	}
+
+	return pass(title, fmt.Sprintf("%v clusters recorded", len(metas)))
+}
+
+func checkKubeConfig(_ bool) Result {
+	const title = "kubeconfig"
+
+	var bad []string
+	for _, p := range kube.LocalPaths() {
+		exist, isDir := base.FileExists(p)
+		if !exist {
+			continue
+		}
+
+		if isDir {
+			bad = append(bad, fmt.Sprintf("%v is a dir", p))
+			continue
+		}
+
+		if _, err := kube.Load(p); err != nil {
+			bad = append(bad, fmt.Sprintf("%v: %v", p, err))
+		}
+	}
+
+	if len(bad) > 0 {
+		return fail(title, strings.Join(bad, "; "), "fix the kubeconfig files, or restore them from backup")
+	}
+
+	return pass(title, strings.Join(kube.LocalPaths(), ", "))
+}
+
+func checkDuplicatePorts(_ bool) Result {
+	const title = "duplicate ports"
+
+	kc, err := kube.LoadLocal()
+	if err != nil {
+		return warn(title, fmt.Sprintf("skipped, err: %v", err), "")
+	}
+
+	dups := duplicatePorts(kc)
+	if len(dups) == 0 {
+		return pass(title, "no local port shared by clusters")
+	}
+
+	var msgs []string
+	for _, p := range sortedPorts(dups) {
+		msgs = append(msgs, fmt.Sprintf("%v => %v", p, dups[p]))
+	}
+
+	return fail(title, strings.Join(msgs, "; "),
+		"delete and re-add one of the clusters, see `cube ports`")
+}
+
+func checkOrphanedCerts(fix bool) Result {
+	const title = "orphaned cert files"
+
+	kcs, err := knownConfigs()
+	if err != nil {
+		return warn(title, fmt.Sprintf("skipped, err: %v", err), "")
+	}
+
+	metas, err := kube.LoadMetas()
+	if err != nil {
+		return warn(title, fmt.Sprintf("skipped, err: %v", err), "")
+	}
+
+	orphans := orphanedCerts(kcs, metas, base.DefaultState())
+	if len(orphans) == 0 {
+		return pass(title, "none")
+	}
+
+	r := warn(title, strings.Join(orphans, ", "), "remove them, or run `cube doctor --fix`")
+	if fix {
+		r.Fixed = true
+		for _, p := range orphans {
+			if err := os.Remove(p); err != nil {
+				r.Fixed = false
+			}
+		}
+	}
+
+	return r
+}
+
+func checkOrphanedMetas(fix bool) Result {
+	const title = "orphaned metadata"
+
+	kcs, err := knownConfigs()
+	if err != nil {
+		return warn(title, fmt.Sprintf("skipped, err: %v", err), "")
+	}
+
+	metas, err := kube.LoadMetas()
+	if err != nil {
+		return warn(title, fmt.Sprintf("skipped, err: %v", err), "")
+	}
+
+	orphans := orphanedMetas(kcs, metas)
+	if len(orphans) == 0 {
+		return pass(title, "none")
+	}
+
+	r := warn(title, fmt.Sprintf("no context for %v, their ports stay reserved", orphans),
+		"run `cube doctor --fix` to release them")
+	if fix {
+		for _, k := range orphans {
+			delete(metas, k)
+		}
+		r.Fixed = metas.Save() == nil
+	}
+
+	return r
+}
+
+// knownPaths returns every kubeconfig file cube may manage, i.e. the
+// default path, files in KUBECONFIG, files in split dir and the ones given
+// by flags, regardless of which ones are loaded right now.
+func knownPaths() []string {
+	paths := []string{base.GetLocalKubePath()}
+	paths = append(paths, filepath.SplitList(os.Getenv(base.KubeConfigEnv))...)
+	paths = append(paths, base.GetSplitPaths()...)
+	for _, p := range []string{base.KubeConfigFlag, base.KubeConfigTarget} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+
+	var ret []string
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+
+		p = filepath.Clean(base.ExpandPath(p))
+		if !seen[p] {
+			seen[p] = true
+			ret = append(ret, p)
+		}
+	}
+
+	return ret
+}
+
+// knownConfigs loads all known kubeconfig files, so that cleanup doesn't
+// depend on the current view. Missing files are skipped, broken ones fail
+// the whole load, as what they refer to is unknown.
+func knownConfigs() ([]*clientcmdapi.Config, error) {
+	var ret []*clientcmdapi.Config
+	for _, p := range knownPaths() {
+		if ok, _ := base.FileExists(p); !ok {
+			continue
+		}
+
+		kc, err := kube.Load(p)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, kc)
+	}
+
+	return ret, nil
+}
+
+func checkTunnels(_ bool) Result {
+	const title = "tunnels"
+
+	kc, err := kube.LoadLocal()
+	if err != nil {
+		return warn(title, fmt.Sprintf("skipped, err: %v", err), "")
+	}
+
+	var down []string
+	var total int
+	for _, k := range sortedContexts(kc) {
+		p, ok := managedPort(kc, k)
+		if !ok {
+			continue
+		}
+
+		total++
+		if !base.IsListening(p) {
+			down = append(down, fmt.Sprintf("%v(%v)", k, p))
+		}
+	}
+
+	if len(down) > 0 {
+		return warn(title, fmt.Sprintf("%v of %v down: %v", len(down), total, strings.Join(down, ", ")),
+			"cube forward --name <context> --op run")
+	}
+
+	return pass(title, fmt.Sprintf("%v up", total))
+}
+
+// managedPort returns local port of given context, if it's reached through
+// local forwarding.
+func managedPort(kc *clientcmdapi.Config, ctxName string) (int, bool) {
+	ctx := kc.Contexts[ctxName]
+	c, ok := kc.Clusters[ctx.Cluster]
+	if !ok {
+		return 0, false
+	}
+
+	h, p, err := kube.GetOccupiedLocalPort(c.Server)
+	if err != nil || h != kube.DefaultHost {
+		return 0, false
+	}
+
+	return p, true
+}
+
+// duplicatePorts returns local ports used by more than one cluster.
+func duplicatePorts(kc *clientcmdapi.Config) map[int][]string {
+	owners := make(map[int][]string)
+	for _, k := range sortedContexts(kc) {
+		if p, ok := managedPort(kc, k); ok {
+			cluster := kc.Contexts[k].Cluster
+			if !contains(owners[p], cluster) {
+				owners[p] = append(owners[p], cluster)
+			}
+		}
+	}
+
+	for p, v := range owners {
+		if len(v) < 2 {
+			delete(owners, p)
+		}
+	}
+
+	return owners
+}
+
+// orphanedCerts returns files in cert dir of given state, which aren't
+// referred by any cluster or user in given kubeconfigs, and don't belong
+// to a remote host in metadata.
+func orphanedCerts(kcs []*clientcmdapi.Config, metas kube.Metas, state base.State) []string {
+	used := make(map[string]bool)
+	for _, kc := range kcs {
+		for _, c := range kc.Clusters {
+			used[filepath.Clean(c.CertificateAuthority)] = true
+		}
+		for _, u := range kc.AuthInfos {
+			used[filepath.Clean(u.ClientCertificate)] = true
+			used[filepath.Clean(u.ClientKey)] = true
+		}
+	}
+
+	for _, meta := range metas {
+		for _, p := range []string{
+			state.CertAuthPath(meta.RemoteHost),
+			state.CertClientPath(meta.RemoteHost),
+			state.CertClientKeyPath(meta.RemoteHost),
+		} {
+			used[p] = true
+		}
+	}
+
+	certDir := state.CertDir()
+	files, err := ioutil.ReadDir(certDir)
+	if err != nil {
+		return nil
+	}
+
+	var ret []string
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		p := filepath.Join(certDir, f.Name())
+		if !used[filepath.Clean(p)] {
+			ret = append(ret, p)
+		}
+	}
+
+	return ret
+}
+
+// orphanedMetas returns contexts in metadata, which aren't in any of
+// given kubeconfigs.
+func orphanedMetas(kcs []*clientcmdapi.Config, metas kube.Metas) []string {
+	var ret []string
+	for _, k := range metas.Names() {
+		found := false
+		for _, kc := range kcs {
+			if _, ok := kc.Contexts[k]; ok {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			ret = append(ret, k)
+		}
+	}
+
+	return ret
+}
+
+func sortedContexts(kc *clientcmdapi.Config) []string {
+	ret := make([]string, 0, len(kc.Contexts))
+	for k := range kc.Contexts {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+func sortedPorts(m map[int][]string) []int {
+	ret := make([]int, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Ints(ret)
+
+	return ret
+}
+
+func contains(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
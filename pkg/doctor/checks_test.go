@@ -0,0 +1,100 @@
+package doctor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+)
+
+func newTestConfig(servers map[string]string) *clientcmdapi.Config {
+	kc := clientcmdapi.NewConfig()
+	for name, srv := range servers {
+		kc.Clusters[name] = &clientcmdapi.Cluster{Server: srv}
+		kc.AuthInfos[name] = &clientcmdapi.AuthInfo{}
+		kc.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+	}
+
+	return kc
+}
+
+func TestDoctor_duplicatePorts(t *testing.T) {
+	assert := assert.New(t)
+
+	kc := newTestConfig(map[string]string{
+		"a": "https://kubernetes:7001",
+		"b": "https://kubernetes:7001",
+		"c": "https://kubernetes:7002",
+		"d": "https://10.0.0.1:7002",
+	})
+	// another context of the same cluster isn't a duplicate.
+	kc.Contexts["c2"] = &clientcmdapi.Context{Cluster: "c", AuthInfo: "c"}
+
+	assert.Equal(map[int][]string{7001: {"a", "b"}}, duplicatePorts(kc))
+}
+
+func TestDoctor_orphanedCerts(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "cube-doctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	state := base.NewState(dir)
+	certDir := state.CertDir()
+	if err := os.MkdirAll(certDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a-ca.crt", "a-client.crt", "a-client.key", "b-ca.crt", "10.0.0.2-ca.crt"} {
+		if err := ioutil.WriteFile(filepath.Join(certDir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	kc := newTestConfig(map[string]string{"a": "https://kubernetes:7001"})
+	kc.Clusters["a"].CertificateAuthority = filepath.Join(certDir, "a-ca.crt")
+	kc.AuthInfos["a"].ClientCertificate = filepath.Join(certDir, "a-client.crt")
+	kc.AuthInfos["a"].ClientKey = filepath.Join(certDir, "a-client.key")
+
+	// certs of remote host in metadata are kept, even if the context lives
+	// in kubeconfig not loaded.
+	metas := kube.Metas{"c": &kube.Meta{RemoteHost: "10.0.0.2"}}
+
+	assert.Equal([]string{filepath.Join(certDir, "b-ca.crt")}, orphanedCerts([]*clientcmdapi.Config{kc}, metas, state))
+}
+
+func TestDoctor_orphanedMetas(t *testing.T) {
+	assert := assert.New(t)
+
+	kcs := []*clientcmdapi.Config{
+		newTestConfig(map[string]string{"a": "https://kubernetes:7001"}),
+		newTestConfig(map[string]string{"b": "https://kubernetes:7002"}),
+	}
+	metas := kube.Metas{"a": &kube.Meta{}, "b": &kube.Meta{}, "c": &kube.Meta{}}
+
+	assert.Equal([]string{"c"}, orphanedMetas(kcs, metas))
+}
+
+func TestDoctor_knownPaths(t *testing.T) {
+	assert := assert.New(t)
+
+	os.Setenv(base.KubeConfigEnv, "/tmp/a.yaml"+string(filepath.ListSeparator)+"/tmp/b.yaml")
+	defer os.Unsetenv(base.KubeConfigEnv)
+
+	base.KubeConfigFlag = "/tmp/a.yaml"
+	defer func() { base.KubeConfigFlag = "" }()
+
+	// flag file already in KUBECONFIG isn't repeated.
+	paths := knownPaths()
+	assert.Equal([]string{base.GetLocalKubePath(), "/tmp/a.yaml", "/tmp/b.yaml"}, paths[:3])
+	assert.NotContains(paths[3:], "/tmp/a.yaml")
+}
@@ -0,0 +1,107 @@
+// Package doctor diagnoses local setup which cube depends on.
+package doctor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the status of a check.
+type Status int
+
+const (
+	Pass Status = iota
+	Warn
+	Fail
+)
+
+func (s Status) String() string {
+	switch s {
+	case Warn:
+		return "WARN"
+	case Fail:
+		return "FAIL"
+	default:
+		return "PASS"
+	}
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Name       string
+	Status     Status
+	Message    string
+	Suggestion string // how to fix the problem, empty if passed
+	Fixed      bool   // whether the problem has been fixed automatically
+}
+
+func (r Result) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "[%v] %v: %v", r.Status, r.Name, r.Message)
+	if r.Fixed {
+		sb.WriteString(" (fixed)")
+	} else if r.Suggestion != "" {
+		fmt.Fprintf(&sb, "\n       fix: %v", r.Suggestion)
+	}
+
+	return sb.String()
+}
+
+// Check diagnoses one prerequisite. If fix is true, safe repairs are
+// applied and reported.
+type Check func(fix bool) Result
+
+// Checks returns all checks, prerequisites first and then cube state.
+func Checks() []Check {
+	return []Check{
+		checkBinary("ssh"),
+		checkBinary("scp"),
+		checkSSHConfig,
+		checkSSHVia,
+		checkBastion,
+		checkHostsEntry,
+		checkStateDirs,
+		checkHistoryFile,
+		checkMetaFile,
+		checkKubeConfig,
+		checkDuplicatePorts,
+		checkOrphanedCerts,
+		checkOrphanedMetas,
+		checkTunnels,
+	}
+}
+
+// Run runs all checks.
+func Run(fix bool) []Result {
+	var ret []Result
+	for _, c := range Checks() {
+		ret = append(ret, c(fix))
+	}
+
+	return ret
+}
+
+// Failed counts failed results which haven't been fixed.
+func Failed(results []Result) int {
+	var n int
+	for _, r := range results {
+		if r.Status == Fail && !r.Fixed {
+			n++
+		}
+	}
+
+	return n
+}
+
+func pass(name, msg string) Result {
+	return Result{Name: name, Status: Pass, Message: msg}
+}
+
+func warn(name, msg, suggestion string) Result {
+	return Result{Name: name, Status: Warn, Message: msg, Suggestion: suggestion}
+}
+
+func fail(name, msg, suggestion string) Result {
+	return Result{Name: name, Status: Fail, Message: msg, Suggestion: suggestion}
+}
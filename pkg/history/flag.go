@@ -0,0 +1,44 @@
+package history
+
+import "strings"
+
+// FlagValues returns values of given flag in recorded commands, most
+// recent first and without duplicates.
+func FlagValues(flag string) ([]string, error) {
+	h := newHist()
+	if h.err != nil {
+		return nil, h.err
+	}
+
+	return flagValues(h.records.lines(), flag), nil
+}
+
+// flagValues extracts values of flag in both `--flag value` and
+// `--flag=value` forms from history lines.
+func flagValues(lines []string, flag string) []string {
+	name := "--" + flag
+	seen := make(map[string]bool)
+	var ret []string
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		tokens := strings.Fields(lines[i])
+		for k, t := range tokens {
+			var v string
+			switch {
+			case t == name && k+1 < len(tokens):
+				v = tokens[k+1]
+			case strings.HasPrefix(t, name+"="):
+				v = strings.TrimPrefix(t, name+"=")
+			}
+
+			if v == "" || strings.HasPrefix(v, "-") || seen[v] {
+				continue
+			}
+
+			seen[v] = true
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,22 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagValues(t *testing.T) {
+	lines := []string{
+		"1  cube add --remote-ip 172.31.7.182 --name-suffix qa",
+		"",
+		"2  cube add --remote-ip=172.31.7.183 --remote-user core",
+		"3  cube add --remote-ip 172.31.7.182",
+		"4  cube add --remote-ip --dry-run",
+		"5  cube list --remote-ips 10.0.0.1",
+	}
+
+	assert.Equal(t, []string{"172.31.7.182", "172.31.7.183"}, flagValues(lines, "remote-ip"))
+	assert.Equal(t, []string{"core"}, flagValues(lines, "remote-user"))
+	assert.Nil(t, flagValues(lines, "ssh-via"))
+}
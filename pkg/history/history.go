@@ -22,7 +22,7 @@ func Write() error {
 }
 
 func Read() error {
-	lines, err := readAllLines(base.DefaultHistoryPath)
+	lines, err := readAllLines(base.DefaultState().HistoryPath())
 	if err != nil {
 		return err
 	}
@@ -53,7 +53,7 @@ type hist struct {
 }
 
 func newHist() hist {
-	lines, err := readAllLines(base.DefaultHistoryPath)
+	lines, err := readAllLines(base.DefaultState().HistoryPath())
 	if err != nil {
 		return hist{err: err}
 	}
@@ -67,7 +67,7 @@ func newHist() hist {
 func (h hist) write() error {
 	content := strings.Join(h.records.lines(), "\n")
 
-	return ioutil.WriteFile(base.DefaultHistoryPath,
+	return ioutil.WriteFile(base.DefaultState().HistoryPath(),
 		[]byte(content), 0666)
 }
 func (h *hist) addNewRecord() {
@@ -102,7 +102,7 @@ func (h *hist) delete(from int) error {
 }
 
 func emptyHistory() error {
-	file, err := os.Create(base.DefaultHistoryPath)
+	file, err := os.Create(base.DefaultState().HistoryPath())
 	if err != nil {
 		return err
 	}
@@ -161,6 +161,9 @@ func initHist(h *hist, lines []string) {
 // https://stackoverflow.com/questions/8757389/reading-file-line-by-line-in-go
 func readAllLines(filename string) ([]string, error) {
 	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,290 @@
+package kube
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	sansDialTimeout   = time.Second
+	sansTunnelTimeout = 10 * time.Second
+)
+
+var (
+	ErrSSHViaNotSet = errors.New("cube: SSH_VIA env not set")
+
+	errNoCertFound = errors.New("cube: no certificate found")
+)
+
+// loadCertBytes returns cert content from inline data, or from file
+// if no data given. nil if neither is available.
+func loadCertBytes(data []byte, path string) ([]byte, error) {
+	if len(data) > 0 {
+		return data, nil
+	}
+
+	if path == "" {
+		return nil, nil
+	}
+
+	return ioutil.ReadFile(path)
+}
+
+// parseCert parses the first certificate in PEM content.
+func parseCert(content []byte) (*x509.Certificate, error) {
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			return nil, errNoCertFound
+		}
+
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// fingerprint returns SHA256 fingerprint of the first certificate in
+// PEM content. If content is not a valid PEM, the raw content is hashed.
+func fingerprint(content []byte) string {
+	if len(content) == 0 {
+		return ""
+	}
+
+	raw := content
+	if cert, err := parseCert(content); err == nil {
+		raw = cert.Raw
+	}
+
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// serverSANs fetches SANs of the server certificate at given address.
+func serverSANs(addr string) ([]string, error) {
+	dialer := &net.Dialer{Timeout: sansDialTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, errNoCertFound
+	}
+
+	var sans []string
+	sans = append(sans, certs[0].DNSNames...)
+	for _, ip := range certs[0].IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	return sans, nil
+}
+
+// tunnelSANs fetches SANs of the server certificate at remote API address,
+// which is only reachable via the jump server, through a temporary ssh
+// tunnel. Output of ssh is written to stderr.
+func tunnelSANs(remoteAPIAddr, via string, stderr io.Writer) ([]string, error) {
+	if via == "" {
+		return nil, ErrSSHViaNotSet
+	}
+
+	port, err := freeLocalPort()
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := StartTunnel(port, remoteAPIAddr, via, sansTunnelTimeout, stderr)
+	if err != nil {
+		return nil, err
+	}
+	defer t.Stop()
+
+	return serverSANs(fmt.Sprintf("127.0.0.1:%d", port))
+}
+
+const (
+	CertKindCA     = "ca"
+	CertKindClient = "client"
+
+	// env var to override default expiry warning window, e.g. `30d` or `72h`
+	envCertWindow     = "CUBE_CERT_WARN_WITHIN"
+	defaultCertWindow = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidCertWindow = errors.New("cube: invalid cert expiry window")
+)
+
+// CertInfo shows expiry of a certificate used by a managed cluster.
+type CertInfo struct {
+	Context  string    `json:"context"`
+	Kind     string    `json:"kind"`
+	Source   string    `json:"source"`
+	Subject  string    `json:"subject,omitempty"`
+	NotAfter time.Time `json:"notAfter,omitempty"`
+	Expiring bool      `json:"expiring"`
+	Expired  bool      `json:"expired"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// ParseCertWindow parses expiry window, which is a Go duration or days,
+// e.g. `72h` or `30d`.
+func ParseCertWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 0 {
+			return 0, errors.Wrapf(ErrInvalidCertWindow, "window: %v", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, errors.Wrapf(ErrInvalidCertWindow, "window: %v", s)
+	}
+
+	return d, nil
+}
+
+// DefaultCertWindowString returns expiry warning window, which can be
+// overridden by `CUBE_CERT_WARN_WITHIN` env.
+func DefaultCertWindowString() string {
+	if v := os.Getenv(envCertWindow); v != "" {
+		return v
+	}
+
+	return fmt.Sprintf("%dd", int(defaultCertWindow.Hours()/24))
+}
+
+// ContextCerts inspects CA and client certificates of given context.
+// Both inline data and file paths are decoded.
+func ContextCerts(kc *clientcmdapi.Config, ctxName string, window time.Duration) []CertInfo {
+	ctx, ok := kc.Contexts[ctxName]
+	if !ok {
+		return nil
+	}
+
+	var ret []CertInfo
+	if c, ok := kc.Clusters[ctx.Cluster]; ok {
+		if info, ok := inspectCert(c.CertificateAuthorityData, c.CertificateAuthority, window); ok {
+			info.Context, info.Kind = ctxName, CertKindCA
+			ret = append(ret, info)
+		}
+	}
+
+	if u, ok := kc.AuthInfos[ctx.AuthInfo]; ok {
+		if info, ok := inspectCert(u.ClientCertificateData, u.ClientCertificate, window); ok {
+			info.Context, info.Kind = ctxName, CertKindClient
+			ret = append(ret, info)
+		}
+	}
+
+	return ret
+}
+
+// inspectCert decodes the certificate, false if neither data nor path given.
+func inspectCert(data []byte, path string, window time.Duration) (CertInfo, bool) {
+	if len(data) == 0 && path == "" {
+		return CertInfo{}, false
+	}
+
+	info := CertInfo{Source: "data"}
+	if len(data) == 0 {
+		info.Source = path
+	}
+
+	content, err := loadCertBytes(data, path)
+	if err != nil {
+		info.Error = err.Error()
+		return info, true
+	}
+
+	cert, err := parseCert(content)
+	if err != nil {
+		info.Error = err.Error()
+		return info, true
+	}
+
+	now := timeNow()
+	info.Subject = cert.Subject.CommonName
+	info.NotAfter = cert.NotAfter
+	info.Expired = now.After(cert.NotAfter)
+	info.Expiring = !info.Expired && now.Add(window).After(cert.NotAfter)
+
+	return info, true
+}
+
+// timeNow returns current time, replaceable in tests.
+var timeNow = time.Now
+
+// EarliestExpiry returns the earliest expiry of certificates used by given
+// context, false if none is found.
+func EarliestExpiry(kc *clientcmdapi.Config, ctxName string) (time.Time, bool) {
+	var ret time.Time
+	var found bool
+
+	for _, info := range ContextCerts(kc, ctxName, 0) {
+		if info.NotAfter.IsZero() {
+			continue
+		}
+
+		if !found || info.NotAfter.Before(ret) {
+			ret = info.NotAfter
+			found = true
+		}
+	}
+
+	return ret, found
+}
+
+// ListCerts inspects certificates of all managed clusters.
+func ListCerts(window time.Duration) ([]CertInfo, error) {
+	kc, err := LoadLocal()
+	if err != nil {
+		return nil, err
+	}
+
+	ctxNames := make([]string, 0, len(kc.Contexts))
+	for k, ctx := range kc.Contexts {
+		c, ok := kc.Clusters[ctx.Cluster]
+		if !ok {
+			continue
+		}
+
+		if h, _, err := GetOccupiedLocalPort(c.Server); err != nil || h != DefaultHost {
+			continue
+		}
+
+		ctxNames = append(ctxNames, k)
+	}
+	sort.Strings(ctxNames)
+
+	var ret []CertInfo
+	for _, k := range ctxNames {
+		ret = append(ret, ContextCerts(kc, k, window)...)
+	}
+
+	return ret, nil
+}
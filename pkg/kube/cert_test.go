@@ -0,0 +1,115 @@
+package kube
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// genTestCert generates a self-signed PEM certificate expiring at notAfter.
+func genTestCert(t *testing.T, cn string, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestParseCertWindow(t *testing.T) {
+	tests := []struct {
+		input  string
+		hasErr bool
+		window time.Duration
+	}{
+		{"30d", false, 30 * 24 * time.Hour},
+		{"72h", false, 72 * time.Hour},
+		{"0d", false, 0},
+		{"-1d", true, 0},
+		{"abc", true, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			assert := assert.New(t)
+			w, err := ParseCertWindow(test.input)
+			assert.Equal(test.hasErr, err != nil)
+			assert.Equal(test.window, w)
+		})
+	}
+}
+
+func TestContextCerts(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	origNow := timeNow
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = origNow }()
+
+	tests := []struct {
+		name     string
+		caExpiry time.Time
+		expiring bool
+		expired  bool
+	}{
+		{"valid", now.Add(90 * 24 * time.Hour), false, false},
+		{"expiring", now.Add(10 * 24 * time.Hour), true, false},
+		{"expired", now.Add(-time.Hour), false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			kc := newTestConfig("kubernetes-qa", "https://kubernetes:7001")
+			kc.Clusters["kubernetes-qa"].CertificateAuthorityData = genTestCert(t, "ca", test.caExpiry)
+			kc.AuthInfos["kubernetes-qa"].ClientCertificateData = genTestCert(t, "admin", now.Add(365*24*time.Hour))
+
+			infos := ContextCerts(kc, "kubernetes-qa", 30*24*time.Hour)
+			assert.Len(infos, 2)
+			assert.Equal(CertKindCA, infos[0].Kind)
+			assert.Equal("ca", infos[0].Subject)
+			assert.Equal(test.expiring, infos[0].Expiring)
+			assert.Equal(test.expired, infos[0].Expired)
+			assert.Equal(CertKindClient, infos[1].Kind)
+			assert.False(infos[1].Expiring)
+
+			expiry, ok := EarliestExpiry(kc, "kubernetes-qa")
+			assert.True(ok)
+			assert.True(expiry.Equal(test.caExpiry.Truncate(time.Second)))
+		})
+	}
+}
+
+func TestContextCerts_invalid(t *testing.T) {
+	assert := assert.New(t)
+
+	kc := newTestConfig("kubernetes-qa", "https://kubernetes:7001")
+	kc.Clusters["kubernetes-qa"].CertificateAuthorityData = []byte("invalid")
+
+	infos := ContextCerts(kc, "kubernetes-qa", 0)
+	assert.Len(infos, 1)
+	assert.NotEmpty(infos[0].Error)
+
+	_, ok := EarliestExpiry(kc, "kubernetes-qa")
+	assert.False(ok)
+}
@@ -0,0 +1,170 @@
+package kube
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ConflictStrategy is how name conflicts are resolved when merging.
+type ConflictStrategy string
+
+const (
+	// ConflictFail returns error on conflict, which is the default.
+	ConflictFail ConflictStrategy = "fail"
+	// ConflictReplace updates existing entries, keeping the local port.
+	ConflictReplace ConflictStrategy = "replace"
+	// ConflictRename picks a unique name suffix.
+	ConflictRename ConflictStrategy = "rename"
+	// ConflictSkip leaves existing entries untouched.
+	ConflictSkip ConflictStrategy = "skip"
+
+	maxRenameAttempts = 100
+)
+
+var (
+	ErrInvalidConflictStrategy = errors.New("cube: invalid conflict strategy")
+	ErrNoUniqueNameSuffix      = errors.New("cube: no unique name suffix available")
+)
+
+// ParseConflictStrategy parses conflict strategy, empty means `fail`.
+func ParseConflictStrategy(s string) (ConflictStrategy, error) {
+	switch cs := ConflictStrategy(strings.ToLower(s)); cs {
+	case "":
+		return ConflictFail, nil
+	case ConflictFail, ConflictReplace, ConflictRename, ConflictSkip:
+		return cs, nil
+	default:
+		return "", errors.Wrapf(ErrInvalidConflictStrategy, "strategy: %v", s)
+	}
+}
+
+// Merge results
+const (
+	MergeAdded    = "added"
+	MergeReplaced = "replaced"
+	MergeRenamed  = "renamed"
+	MergeSkipped  = "skipped"
+	MergeRotated  = "rotated"
+)
+
+// MergeSummary reports what's done by merge.
+type MergeSummary struct {
+	Strategy   ConflictStrategy
+	Conflicts  []string
+	Warnings   []string
+	Result     string
+	Identity   Identity
+	Related    string // existing context the merged cluster relates to
+	NameSuffix string
+	Context    string
+	LocalPort  int
+}
+
+func (s MergeSummary) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "result: %v\n", s.Result)
+	fmt.Fprintf(&sb, "context: %v\n", s.Context)
+	fmt.Fprintf(&sb, "name-suffix: %v\n", s.NameSuffix)
+	fmt.Fprintf(&sb, "local-port: %v\n", s.LocalPort)
+	fmt.Fprintf(&sb, "on-conflict: %v\n", s.Strategy)
+	if s.Related != "" {
+		fmt.Fprintf(&sb, "identity: %v, related to %v\n", s.Identity, s.Related)
+	} else {
+		fmt.Fprintf(&sb, "identity: %v\n", s.Identity)
+	}
+	for _, c := range s.Conflicts {
+		fmt.Fprintf(&sb, "conflict: %v\n", c)
+	}
+	for _, w := range s.Warnings {
+		fmt.Fprintf(&sb, "warning: %v\n", w)
+	}
+
+	return sb.String()
+}
+
+// findConflicts returns all entries in main kubeconfig which have the same
+// names as the cluster to be merged.
+func (m *merger) findConflicts(names Names) []error {
+	var errs []error
+
+	if _, ok := m.mainKC.Clusters[names.Cluster]; ok {
+		errs = append(errs, errors.Wrapf(ErrClusterAlreadyExists, "name: %v", names.Cluster))
+	}
+
+	if _, ok := m.mainKC.AuthInfos[names.User]; ok {
+		errs = append(errs, errors.Wrapf(ErrUserAlreadyExists, "name: %v", names.User))
+	}
+
+	if _, ok := m.mainKC.Contexts[names.Context]; ok {
+		errs = append(errs, errors.Wrapf(ErrContextAlreadyExists, "name: %v", names.Context))
+	}
+
+	return errs
+}
+
+// uniqueNames picks a unique name suffix in the form of `<suffix>-<n>`.
+func (m *merger) uniqueNames() (string, Names, error) {
+	used := make(map[string]bool)
+	for _, v := range m.metas {
+		used[v.NameSuffix] = true
+	}
+
+	for k := 2; k < maxRenameAttempts; k++ {
+		suffix := fmt.Sprintf("%v-%d", m.opts.NameSuffix, k)
+		if used[suffix] {
+			continue
+		}
+
+		names, err := m.renderNames(suffix)
+		if err != nil {
+			return "", Names{}, err
+		}
+
+		if len(m.findConflicts(names)) == 0 {
+			return suffix, names, nil
+		}
+	}
+
+	return "", Names{}, errors.Wrapf(ErrNoUniqueNameSuffix, "suffix: %v", m.opts.NameSuffix)
+}
+
+// existingLocalPort returns the local port used by existing entries with
+// given names, 0 if not found.
+func (m *merger) existingLocalPort(names Names) int {
+	clusterName := names.Cluster
+	if _, ok := m.mainKC.Clusters[clusterName]; !ok {
+		if ctx, ok := m.mainKC.Contexts[names.Context]; ok {
+			clusterName = ctx.Cluster
+		}
+	}
+
+	c, ok := m.mainKC.Clusters[clusterName]
+	if !ok {
+		return 0
+	}
+
+	_, p, err := GetOccupiedLocalPort(c.Server)
+	if err != nil {
+		return 0
+	}
+
+	return p
+}
+
+// keepLocations makes replaced entries stay in the files they're loaded from.
+func (m *merger) keepLocations(names Names) {
+	if c, ok := m.mainKC.Clusters[names.Cluster]; ok {
+		m.inCK.Cluster.LocationOfOrigin = c.LocationOfOrigin
+	}
+
+	if u, ok := m.mainKC.AuthInfos[names.User]; ok && m.inCK.User != nil {
+		m.inCK.User.LocationOfOrigin = u.LocationOfOrigin
+	}
+
+	if ctx, ok := m.mainKC.Contexts[names.Context]; ok {
+		m.inCK.Ctx.LocationOfOrigin = ctx.LocationOfOrigin
+	}
+}
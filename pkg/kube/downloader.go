@@ -2,10 +2,12 @@ package kube
 
 import (
 	"errors"
+	"io"
 	"path/filepath"
 	"strings"
 
 	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/log"
 	"github.com/shohi/cube/pkg/scp"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
@@ -21,11 +23,36 @@ var (
 	ErrConfigInvalid     = errors.New("cube: remote kubeconfig must have only one cluster")
 )
 
+// Transport fetches files from remote host.
+type Transport interface {
+	Fetch(remoteAddr, remotePath, localPath string) error
+}
+
+// SCPTransport fetches files by scp.
+type SCPTransport struct {
+	// Stdout and Stderr receive output of scp, discarded if nil.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (t SCPTransport) Fetch(remoteAddr, remotePath, localPath string) error {
+	return scp.TransferFile(scp.TransferConfig{
+		RemoteAddr: remoteAddr,
+		RemotePath: remotePath,
+		LocalPath:  localPath,
+		Stdout:     t.Stdout,
+		Stderr:     t.Stderr,
+	})
+}
+
 // Downloader download kubernetes config for remote cluster.
 // also download cert files if necessary.
 type Downloader struct {
 	remoteAddr string
 	hostIP     string
+	transport  Transport
+	state      base.State
+	log        *log.Logger
 
 	kc *clientcmdapi.Config
 
@@ -40,12 +67,26 @@ type DownloadResult struct {
 
 }
 
-// NewDownloader create a new remote config downloader.
-func NewDownloader(remoteAddr string) *Downloader {
+// NewDownloader create a new remote config downloader. If transport is
+// nil, SCPTransport is used.
+func NewDownloader(remoteAddr string, transport Transport) *Downloader {
+	return newDownloader(remoteAddr, transport, base.DefaultState(), log.Default())
+}
+
+// newDownloader creates downloader which keeps caches and certs under
+// given state.
+func newDownloader(remoteAddr string, transport Transport, state base.State, lg *log.Logger) *Downloader {
+	if transport == nil {
+		transport = SCPTransport{}
+	}
+
 	hostIP := base.ExtractHost(remoteAddr)
 	return &Downloader{
 		remoteAddr: remoteAddr,
 		hostIP:     hostIP,
+		transport:  transport,
+		state:      state,
+		log:        lg,
 	}
 }
 
@@ -70,14 +111,10 @@ func (d *Downloader) Download() (DownloadResult, error) {
 }
 
 func (d *Downloader) downloadK8sConfig() error {
-	p := LocalCachePath(d.remoteAddr)
+	p := cachePath(d.state, d.remoteAddr)
 
 	// TODO: check whether the file is empty
-	err := scp.TransferFile(scp.TransferConfig{
-		LocalPath:  p,
-		RemoteAddr: d.remoteAddr,
-		RemotePath: DefaultKubeConfigPath,
-	})
+	err := d.transport.Fetch(d.remoteAddr, DefaultKubeConfigPath, p)
 	if err != nil {
 		return err
 	}
@@ -92,6 +129,9 @@ func (d *Downloader) downloadK8sConfig() error {
 	}
 
 	d.ck = getClusterKeyInfo(d.kc, d.clusterName)
+	if d.ck.User == nil {
+		d.log.Warn("no auth info with given user", "user", d.ck.Ctx.AuthInfo)
+	}
 
 	return nil
 }
@@ -187,12 +227,8 @@ func (d *Downloader) downloadCertFiles() error {
 	}
 
 	// download auth cert and also update corresponding info
-	localAuthPath := base.GenLocalCertAuthPath(d.remoteAddr)
-	err := scp.TransferFile(scp.TransferConfig{
-		LocalPath:  localAuthPath,
-		RemoteAddr: d.remoteAddr,
-		RemotePath: d.ck.Cluster.CertificateAuthority,
-	})
+	localAuthPath := d.state.CertAuthPath(d.remoteAddr)
+	err := d.transport.Fetch(d.remoteAddr, d.ck.Cluster.CertificateAuthority, localAuthPath)
 
 	if err != nil {
 		return err
@@ -200,12 +236,8 @@ func (d *Downloader) downloadCertFiles() error {
 	d.ck.Cluster.CertificateAuthority = localAuthPath
 
 	// client crt
-	localClientCertPath := base.GenLocalCertClientPath(d.remoteAddr)
-	err = scp.TransferFile(scp.TransferConfig{
-		LocalPath:  localClientCertPath,
-		RemoteAddr: d.remoteAddr,
-		RemotePath: d.ck.User.ClientCertificate,
-	})
+	localClientCertPath := d.state.CertClientPath(d.remoteAddr)
+	err = d.transport.Fetch(d.remoteAddr, d.ck.User.ClientCertificate, localClientCertPath)
 
 	if err != nil {
 		return err
@@ -213,12 +245,8 @@ func (d *Downloader) downloadCertFiles() error {
 	d.ck.User.ClientCertificate = localClientCertPath
 
 	// client key
-	localClientKeyPath := base.GenLocalCertClientKeyPath(d.remoteAddr)
-	err = scp.TransferFile(scp.TransferConfig{
-		LocalPath:  localClientKeyPath,
-		RemoteAddr: d.remoteAddr,
-		RemotePath: d.ck.User.ClientKey,
-	})
+	localClientKeyPath := d.state.CertClientKeyPath(d.remoteAddr)
+	err = d.transport.Fetch(d.remoteAddr, d.ck.User.ClientKey, localClientKeyPath)
 
 	if err != nil {
 		return err
@@ -231,6 +259,10 @@ func (d *Downloader) downloadCertFiles() error {
 // LocalCachePath returns cache path for remote kubectl config by convention.
 // that's, `~/.config/cube/cache/$HOST`.
 func LocalCachePath(remoteAddr string) string {
+	return cachePath(base.DefaultState(), remoteAddr)
+}
+
+func cachePath(state base.State, remoteAddr string) string {
 	filename := base.ExtractHost(remoteAddr)
-	return filepath.Join(base.DefaultCacheDir, filename+".yaml")
+	return filepath.Join(state.CacheDir(), filename+".yaml")
 }
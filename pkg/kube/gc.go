@@ -0,0 +1,97 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Garbage is dangling entries in kubeconfig.
+type Garbage struct {
+	Contexts []string // contexts referring to missing cluster or user
+	Clusters []string // clusters not used by any context
+	Users    []string // users not used by any context
+}
+
+// Empty checks whether there's nothing to collect.
+func (g Garbage) Empty() bool {
+	return len(g.Contexts) == 0 && len(g.Clusters) == 0 && len(g.Users) == 0
+}
+
+func (g Garbage) String() string {
+	var sb strings.Builder
+	write := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "%v:\n", title)
+		for _, v := range items {
+			fmt.Fprintf(&sb, "  - %v\n", v)
+		}
+	}
+
+	write("contexts", g.Contexts)
+	write("clusters", g.Clusters)
+	write("users", g.Users)
+
+	return sb.String()
+}
+
+// FindGarbage finds dangling contexts, clusters and users. Clusters and
+// users only used by dangling contexts are dangling too.
+func FindGarbage(kc *clientcmdapi.Config) Garbage {
+	var g Garbage
+
+	usedClusters := make(map[string]bool)
+	usedUsers := make(map[string]bool)
+	for k, v := range kc.Contexts {
+		_, clusterOK := kc.Clusters[v.Cluster]
+		_, userOK := kc.AuthInfos[v.AuthInfo]
+		if !clusterOK || (v.AuthInfo != "" && !userOK) {
+			g.Contexts = append(g.Contexts, k)
+			continue
+		}
+
+		usedClusters[v.Cluster] = true
+		usedUsers[v.AuthInfo] = true
+	}
+
+	for k := range kc.Clusters {
+		if !usedClusters[k] {
+			g.Clusters = append(g.Clusters, k)
+		}
+	}
+
+	for k := range kc.AuthInfos {
+		if !usedUsers[k] {
+			g.Users = append(g.Users, k)
+		}
+	}
+
+	sort.Strings(g.Contexts)
+	sort.Strings(g.Clusters)
+	sort.Strings(g.Users)
+
+	return g
+}
+
+// Remove deletes garbage from kubeconfig, and unsets current-context if
+// it's removed.
+func (g Garbage) Remove(kc *clientcmdapi.Config) {
+	for _, k := range g.Contexts {
+		delete(kc.Contexts, k)
+		if kc.CurrentContext == k {
+			kc.CurrentContext = ""
+		}
+	}
+
+	for _, k := range g.Clusters {
+		delete(kc.Clusters, k)
+	}
+
+	for _, k := range g.Users {
+		delete(kc.AuthInfos, k)
+	}
+}
@@ -0,0 +1,30 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestFindGarbage(t *testing.T) {
+	assert := assert.New(t)
+
+	kc := newTestConfig("qa", "https://kubernetes:7001")
+	kc.Clusters["unused"] = &clientcmdapi.Cluster{Server: "https://kubernetes:7002"}
+	kc.AuthInfos["unused"] = &clientcmdapi.AuthInfo{}
+	kc.Clusters["orphan"] = &clientcmdapi.Cluster{Server: "https://kubernetes:7003"}
+	kc.Contexts["no-user"] = &clientcmdapi.Context{Cluster: "orphan", AuthInfo: "missing"}
+	kc.Contexts["no-auth"] = &clientcmdapi.Context{Cluster: "qa"}
+	kc.CurrentContext = "no-user"
+
+	g := FindGarbage(kc)
+	assert.Equal([]string{"no-user"}, g.Contexts)
+	assert.Equal([]string{"orphan", "unused"}, g.Clusters)
+	assert.Equal([]string{"unused"}, g.Users)
+
+	g.Remove(kc)
+	assert.Equal("", kc.CurrentContext)
+	assert.Len(kc.Contexts, 2)
+	assert.True(FindGarbage(kc).Empty())
+}
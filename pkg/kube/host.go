@@ -69,3 +69,21 @@ func getShortContext(kctx string) string {
 
 	return fmt.Sprintf("%v-%v", hostname, namesuffix)
 }
+
+// getNameSuffix returns name suffix from context name in legacy format.
+// e.g full context name - `kubernetes-admin@172.31.7.182:6443-test`
+// then name suffix is `test`.
+func getNameSuffix(kctx string) string {
+	tokens := strings.Split(kctx, SepAt)
+	if len(tokens) < 2 {
+		return ""
+	}
+
+	remain := strings.Join(tokens[1:], SepAt)
+	tokens = strings.Split(remain, SepHyphen)
+	if len(tokens) < 2 {
+		return ""
+	}
+
+	return strings.Join(tokens[1:], SepHyphen)
+}
@@ -0,0 +1,89 @@
+package kube
+
+import (
+	"net"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+var (
+	ErrHostNotResolved = errors.New("cube: host not resolved")
+	ErrHostNotLoopback = errors.New("cube: host not resolved to loopback")
+)
+
+// DefaultHostsFile is the hosts file where DefaultHost is mapped to loopback.
+const DefaultHostsFile = "/etc/hosts"
+
+// HostsBlock is the block of hosts file managed by cube.
+var HostsBlock = base.MarkedBlock{
+	Begin: "# BEGIN cube managed hosts, generated by `cube hosts install`",
+	End:   "# END cube managed hosts",
+}
+
+// lookupHost resolves host, replaceable in tests.
+var lookupHost = net.LookupHost
+
+// HostsFile returns hosts file path from env `CUBE_HOSTS_FILE`, or DefaultHostsFile.
+func HostsFile() string {
+	if p := os.Getenv("CUBE_HOSTS_FILE"); p != "" {
+		return p
+	}
+
+	return DefaultHostsFile
+}
+
+// CheckHostMapping checks whether DefaultHost resolves to loopback, and
+// returns resolved addrs.
+func CheckHostMapping() ([]string, error) {
+	addrs, err := lookupHost(DefaultHost)
+	if err != nil || len(addrs) == 0 {
+		return nil, errors.Wrapf(ErrHostNotResolved, "host: %v", DefaultHost)
+	}
+
+	for _, a := range addrs {
+		ip := net.ParseIP(a)
+		if ip == nil || !ip.IsLoopback() {
+			return addrs, errors.Wrapf(ErrHostNotLoopback, "host: %v, addrs: %v", DefaultHost, addrs)
+		}
+	}
+
+	return addrs, nil
+}
+
+// HostsEntry returns the entry mapping DefaultHost to loopback, wrapped
+// with markers of HostsBlock.
+func HostsEntry() string {
+	return HostsBlock.Wrap("127.0.0.1\t" + DefaultHost)
+}
+
+// HasHostMapping checks whether hosts file content maps DefaultHost to
+// loopback, either in cube managed block or added by hand.
+func HasHostMapping(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		ip := net.ParseIP(fields[0])
+		if ip == nil || !ip.IsLoopback() {
+			continue
+		}
+
+		for _, name := range fields[1:] {
+			if name == DefaultHost {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+package kube
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckHostMapping(t *testing.T) {
+	defer func(fn func(string) ([]string, error)) { lookupHost = fn }(lookupHost)
+
+	var tests = []struct {
+		name     string
+		addrs    []string
+		lookErr  error
+		expected error
+	}{
+		{"loopback", []string{"127.0.0.1", "::1"}, nil, nil},
+		{"not loopback", []string{"10.0.0.1"}, nil, ErrHostNotLoopback},
+		{"not resolved", nil, errors.New("no such host"), ErrHostNotResolved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookupHost = func(string) ([]string, error) { return tt.addrs, tt.lookErr }
+
+			_, err := CheckHostMapping()
+			assert.True(t, errors.Is(err, tt.expected), "error: %v", err)
+		})
+	}
+}
+
+func TestHasHostMapping(t *testing.T) {
+	var tests = []struct {
+		content  string
+		expected bool
+	}{
+		{"127.0.0.1\tlocalhost\n", false},
+		{"127.0.0.1\tlocalhost kubernetes\n", true},
+		{"# 127.0.0.1 kubernetes\n", false},
+		{"10.0.0.1 kubernetes\n", false},
+		{"127.0.0.1 localhost\n" + HostsEntry(), true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, HasHostMapping(tt.content), tt.content)
+	}
+}
@@ -0,0 +1,188 @@
+package kube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+// Identity is how the cluster to be merged relates to existing ones.
+type Identity int
+
+const (
+	// IdentityNew means the cluster hasn't been merged.
+	IdentityNew Identity = iota
+	// IdentityMerged means the cluster has already been merged.
+	IdentityMerged
+	// IdentityRotated means the cluster has been merged, but its CA or
+	// client credentials have been rotated since.
+	IdentityRotated
+	// IdentitySharedCA means a different cluster sharing the same CA
+	// has been merged.
+	IdentitySharedCA
+)
+
+func (i Identity) String() string {
+	switch i {
+	case IdentityMerged:
+		return "already-merged"
+	case IdentityRotated:
+		return "rotated-credentials"
+	case IdentitySharedCA:
+		return "shared-ca"
+	default:
+		return "new"
+	}
+}
+
+// identityMatch is the identity of cluster to be merged, together with the
+// existing context it relates to.
+type identityMatch struct {
+	Identity Identity
+	Context  string
+}
+
+// fetchSANs fetches SANs of server certificate at local address, and
+// fetchRemoteSANs at remote API address through ssh tunnel. They are
+// replaceable in tests.
+var (
+	fetchSANs       = serverSANs
+	fetchRemoteSANs = tunnelSANs
+)
+
+// identify checks how the cluster to be merged relates to the existing
+// clusters. Managed clusters are compared by remote API address, CA
+// fingerprint, client credentials and server cert SANs. Unmanaged
+// clusters are only compared by CA. Output of ssh tunnels is written to
+// stderr.
+func identify(kc *clientcmdapi.Config, metas Metas, in ClusterKeyInfo, inMeta *Meta, via string, stderr io.Writer) identityMatch {
+	ctxNames := make([]string, 0, len(kc.Contexts))
+	for k := range kc.Contexts {
+		ctxNames = append(ctxNames, k)
+	}
+	sort.Strings(ctxNames)
+
+	inCA := caFingerprint(in.Cluster)
+	inCred := credFingerprint(in.User)
+
+	var ret identityMatch
+	for _, k := range ctxNames {
+		ctx := kc.Contexts[k]
+		cluster, ok := kc.Clusters[ctx.Cluster]
+		if !ok {
+			continue
+		}
+
+		sameCA := inCA != "" && inCA == caFingerprint(cluster)
+
+		meta := metas.Get(k)
+		if meta == nil {
+			meta, _ = legacyMeta(kc, k)
+		}
+
+		var id Identity
+		switch {
+		case meta == nil:
+			if sameCA {
+				id = IdentityMerged
+			}
+		case meta.RemoteAPIAddr() == inMeta.RemoteAPIAddr():
+			id = IdentityRotated
+			if sameCA && inCred == credFingerprint(kc.AuthInfos[ctx.AuthInfo]) {
+				id = IdentityMerged
+			}
+		case sameCA:
+			id = IdentitySharedCA
+			if sameServer(meta, inMeta, via, stderr) {
+				id = IdentityMerged
+			}
+		}
+
+		if id != IdentityNew && higherIdentity(id, ret.Identity) {
+			ret = identityMatch{Identity: id, Context: k}
+		}
+	}
+
+	return ret
+}
+
+// higherIdentity checks whether identity a is more specific than b,
+// i.e. merged > rotated > shared-ca > new.
+func higherIdentity(a, b Identity) bool {
+	rank := map[Identity]int{
+		IdentityNew:      0,
+		IdentitySharedCA: 1,
+		IdentityRotated:  2,
+		IdentityMerged:   3,
+	}
+
+	return rank[a] > rank[b]
+}
+
+// sameServer checks whether the existing cluster and the cluster to be
+// merged present the same server cert SANs. The existing one is reached
+// by its local forwarding if running, the other by a temporary tunnel
+// via the jump server. It's false if either one is unreachable.
+func sameServer(meta, inMeta *Meta, via string, stderr io.Writer) bool {
+	var existing []string
+	var err error
+	if meta.LocalPort > 0 && base.IsListening(meta.LocalPort) {
+		existing, err = fetchSANs(fmt.Sprintf("127.0.0.1:%d", meta.LocalPort))
+	} else {
+		existing, err = fetchRemoteSANs(meta.RemoteAPIAddr(), via, stderr)
+	}
+	if err != nil || len(existing) == 0 {
+		return false
+	}
+
+	incoming, err := fetchRemoteSANs(inMeta.RemoteAPIAddr(), via, stderr)
+	if err != nil || len(incoming) == 0 {
+		return false
+	}
+
+	sort.Strings(existing)
+	sort.Strings(incoming)
+
+	return reflect.DeepEqual(existing, incoming)
+}
+
+// caFingerprint returns fingerprint of cluster CA, either from data or file.
+func caFingerprint(c *clientcmdapi.Cluster) string {
+	if c == nil {
+		return ""
+	}
+
+	content, err := loadCertBytes(c.CertificateAuthorityData, c.CertificateAuthority)
+	if err != nil {
+		return ""
+	}
+
+	return fingerprint(content)
+}
+
+// credFingerprint returns fingerprint of user credentials, which is
+// either token or client certificate.
+func credFingerprint(u *clientcmdapi.AuthInfo) string {
+	if u == nil {
+		return ""
+	}
+
+	if u.Token != "" {
+		sum := sha256.Sum256([]byte(u.Token))
+		return hex.EncodeToString(sum[:])
+	}
+
+	content, err := loadCertBytes(u.ClientCertificateData, u.ClientCertificate)
+	if err != nil {
+		return ""
+	}
+
+	return fingerprint(content)
+}
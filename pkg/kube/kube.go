@@ -5,9 +5,10 @@ import (
 	"io/ioutil"
 	"strings"
 
-	"github.com/shohi/cube/pkg/base"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/matcher"
 )
 
 const (
@@ -70,36 +71,6 @@ func Write(kc *clientcmdapi.Config) ([]byte, error) {
 	return clientcmd.Write(*kc)
 }
 
-func findCluster(kc *clientcmdapi.Config, inCluster *clientcmdapi.Cluster) (string, bool) {
-	var cluster string
-	var found = false
-
-	for k, v := range kc.Clusters {
-		if isEqual(v, inCluster) {
-			found = true
-			cluster = k
-			break
-		}
-	}
-
-	return cluster, found
-}
-
-// isEqual checks whether the two cluster is equal based on CertificateAuthority info.
-func isEqual(k1, k2 *clientcmdapi.Cluster) bool {
-	if len(k1.CertificateAuthorityData) > 0 &&
-		string(k1.CertificateAuthorityData) == string(k2.CertificateAuthorityData) {
-		return true
-	}
-
-	if len(k1.CertificateAuthority) > 0 &&
-		k1.CertificateAuthority == k2.CertificateAuthority {
-		return true
-	}
-
-	return false
-}
-
 func getContext(kc *clientcmdapi.Config, cluster string) (string, *clientcmdapi.Context) {
 	var kCtx *clientcmdapi.Context
 	var kName string
@@ -123,23 +94,21 @@ func getContext(kc *clientcmdapi.Config, cluster string) (string, *clientcmdapi.
 }
 
 func getUser(kc *clientcmdapi.Config, userName string) *clientcmdapi.AuthInfo {
-	user, ok := kc.AuthInfos[userName]
-	if !ok {
-		// TODO: add WARN
-		fmt.Printf("[WARN] no auth info with given user - %v\n", userName)
-	}
-
-	return user
+	return kc.AuthInfos[userName]
 }
 
-func FindContextsByName(kc *clientcmdapi.Config, name string, filter func(string) bool) map[string]*clientcmdapi.Context {
+// FindContexts returns contexts matching include but not exclude. A context
+// matches if any of its names matches, see ContextNames. Nil exclude
+// excludes nothing.
+func FindContexts(kc *clientcmdapi.Config, metas Metas, include, exclude matcher.Matcher) map[string]*clientcmdapi.Context {
 	var result = make(map[string]*clientcmdapi.Context)
 	for k, v := range kc.Contexts {
-		if !strings.Contains(k, name) {
+		names := ContextNames(metas, k)
+		if !matcher.Any(include, names...) {
 			continue
 		}
 
-		if filter != nil && !filter(k) {
+		if exclude != nil && matcher.Any(exclude, names...) {
 			continue
 		}
 
@@ -149,6 +118,38 @@ func FindContextsByName(kc *clientcmdapi.Config, name string, filter func(string
 	return result
 }
 
+// ContextNames returns names which the context can be referred by, i.e.
+// context name, and for managed clusters, name suffix and short name in
+// the format of `host-suffix`. Contexts without metadata are only
+// referred by context name, so that contexts not managed by cube are
+// never selected by a suffix.
+func ContextNames(metas Metas, ctxName string) []string {
+	names := []string{ctxName}
+
+	m := metas.Get(ctxName)
+	if m == nil {
+		return names
+	}
+
+	if m.RemoteHost != "" {
+		return append(names, m.NameSuffix, fmt.Sprintf("%v-%v", m.RemoteHost, m.NameSuffix))
+	}
+
+	if suffix := getNameSuffix(ctxName); suffix != "" {
+		return append(names, suffix, getShortContext(ctxName))
+	}
+
+	return names
+}
+
+// ShortName returns short name of managed cluster, i.e. `host-suffix`,
+// or context name for others.
+func ShortName(metas Metas, ctxName string) string {
+	names := ContextNames(metas, ctxName)
+
+	return names[len(names)-1]
+}
+
 // Load reads kubeconfig from file
 func Load(configPath string) (*clientcmdapi.Config, error) {
 	content, err := ioutil.ReadFile(configPath)
@@ -163,11 +164,3 @@ func Load(configPath string) (*clientcmdapi.Config, error) {
 
 	return kc, nil
 }
-
-// remote API address is composed of ip from remoteAddr and port for apiSrv.
-// e.g. 172.10.0.1:6443
-func genRemoteAPIAddr(remoteAddr, apiSrv string) string {
-	h := base.GetHostname(remoteAddr)
-	p, _ := base.GetPort(apiSrv)
-	return fmt.Sprintf("%v:%v", h, p)
-}
@@ -0,0 +1,75 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+func TestFindContexts(t *testing.T) {
+	kc := newTestConfig("kubernetes-admin@172.31.7.182:6443-qa", "https://kubernetes:7001")
+	for _, name := range []string{"kubernetes-admin@172.31.7.183:6443-qa2", "prod", "kind-qa"} {
+		kc.Contexts[name] = kc.Contexts["kubernetes-admin@172.31.7.182:6443-qa"]
+	}
+	kc.Contexts["preqa"] = kc.Contexts["prod"]
+	kc.Contexts["admin@prod-eu"] = kc.Contexts["prod"]
+
+	metas := Metas{
+		"kubernetes-admin@172.31.7.182:6443-qa":  &Meta{NameSuffix: "qa", RemoteHost: "172.31.7.182", RemotePort: 6443},
+		"kubernetes-admin@172.31.7.183:6443-qa2": &Meta{NameSuffix: "qa2", RemoteHost: "172.31.7.183", RemotePort: 6443},
+		"prod":                                   &Meta{NameSuffix: "production", RemoteHost: "172.31.7.184", RemotePort: 6443},
+	}
+
+	tests := []struct {
+		name     string
+		mode     matcher.Mode
+		pattern  string
+		exclude  string
+		expected []string
+	}{
+		{"exact suffix", matcher.Exact, "qa", "", []string{"kubernetes-admin@172.31.7.182:6443-qa"}},
+		{"exact short name", matcher.Exact, "172.31.7.183-qa2", "", []string{"kubernetes-admin@172.31.7.183:6443-qa2"}},
+		{"exact meta suffix", matcher.Exact, "production", "", []string{"prod"}},
+		{"exact unmanaged suffix", matcher.Exact, "eu", "", nil},
+		{"exact unmanaged short name", matcher.Exact, "prod-eu", "", nil},
+		{"exact unmanaged name", matcher.Exact, "admin@prod-eu", "", []string{"admin@prod-eu"}},
+		{"prefix", matcher.Prefix, "qa", "", []string{"kubernetes-admin@172.31.7.182:6443-qa", "kubernetes-admin@172.31.7.183:6443-qa2"}},
+		{"glob", matcher.Glob, "*qa", "", []string{"kubernetes-admin@172.31.7.182:6443-qa", "kind-qa", "preqa"}},
+		{"regex with exclude", matcher.Regex, "qa", "kind", []string{"kubernetes-admin@172.31.7.182:6443-qa", "kubernetes-admin@172.31.7.183:6443-qa2", "preqa"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			include, err := matcher.New(test.mode, test.pattern)
+			assert.Nil(err)
+			exclude, err := matcher.NewExclude(test.exclude)
+			assert.Nil(err)
+
+			var names []string
+			for k := range FindContexts(kc, metas, include, exclude) {
+				names = append(names, k)
+			}
+			assert.ElementsMatch(test.expected, names)
+		})
+	}
+}
+
+func TestContextNames(t *testing.T) {
+	assert := assert.New(t)
+
+	metas := Metas{
+		"kubernetes-admin@172.31.7.182:6443-qa": &Meta{NameSuffix: "qa"},
+	}
+
+	// unmanaged context is only referred by its full name.
+	assert.Equal([]string{"admin@prod-eu"}, ContextNames(nil, "admin@prod-eu"))
+	assert.Equal([]string{"kubernetes-admin@172.31.7.183:6443-qa"}, ContextNames(metas, "kubernetes-admin@172.31.7.183:6443-qa"))
+
+	// managed context with metadata lacking remote host falls back to its name.
+	assert.Equal([]string{"kubernetes-admin@172.31.7.182:6443-qa", "qa", "172.31.7.182-qa"},
+		ContextNames(metas, "kubernetes-admin@172.31.7.182:6443-qa"))
+}
@@ -0,0 +1,151 @@
+package kube
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var (
+	ErrInvalidSelector = errors.New("cube: invalid label selector")
+	ErrInvalidLabel    = errors.New("cube: invalid label")
+	ErrLabelExists     = errors.New("cube: label already exists, use --overwrite to update")
+)
+
+// ParseSelector parses label selector in kubectl syntax, e.g.
+// `env=prod,region!=us`. Empty selector matches everything.
+func ParseSelector(s string) (labels.Selector, error) {
+	sel, err := labels.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidSelector, "selector: %v, error: %v", s, err)
+	}
+
+	return sel, nil
+}
+
+// ParseLabels parses labels in the format of `k1=v1,k2=v2`.
+func ParseLabels(s string) (map[string]string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	set, err := labels.ConvertSelectorToLabelsMap(s)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidLabel, "labels: %v, error: %v", s, err)
+	}
+
+	for k, v := range set {
+		if err := validateLabel(k, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return set, nil
+}
+
+// ParseLabelChanges parses label changes, `key=value` to set and `key-` to remove.
+func ParseLabelChanges(args []string) (map[string]string, []string, error) {
+	set := make(map[string]string)
+	var remove []string
+
+	for _, arg := range args {
+		if strings.HasSuffix(arg, "-") && !strings.Contains(arg, "=") {
+			key := strings.TrimSuffix(arg, "-")
+			if err := validateLabel(key, ""); err != nil {
+				return nil, nil, err
+			}
+			remove = append(remove, key)
+			continue
+		}
+
+		tokens := strings.SplitN(arg, "=", 2)
+		if len(tokens) != 2 {
+			return nil, nil, errors.Wrapf(ErrInvalidLabel, "label: %v", arg)
+		}
+
+		if err := validateLabel(tokens[0], tokens[1]); err != nil {
+			return nil, nil, err
+		}
+		set[tokens[0]] = tokens[1]
+	}
+
+	return set, remove, nil
+}
+
+func validateLabel(key, value string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return errors.Wrapf(ErrInvalidLabel, "key: %v, error: %v", key, strings.Join(errs, "; "))
+	}
+
+	if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+		return errors.Wrapf(ErrInvalidLabel, "value: %v, error: %v", value, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// Labels returns labels of given context, empty if no metadata found.
+func (ms Metas) Labels(ctxName string) labels.Set {
+	if m := ms.Get(ctxName); m != nil {
+		return m.Labels
+	}
+
+	return nil
+}
+
+// SelectContexts returns contexts whose labels match the selector.
+func SelectContexts(ctxs map[string]*clientcmdapi.Context, metas Metas, sel labels.Selector) map[string]*clientcmdapi.Context {
+	if sel == nil || sel.Empty() {
+		return ctxs
+	}
+
+	ret := make(map[string]*clientcmdapi.Context)
+	for k, v := range ctxs {
+		if sel.Matches(metas.Labels(k)) {
+			ret[k] = v
+		}
+	}
+
+	return ret
+}
+
+// ApplyLabels sets and removes labels of given context. Existing labels are
+// only updated when overwrite is true. Metadata is created if not found,
+// which for legacy contexts is parsed from context name.
+func ApplyLabels(kc *clientcmdapi.Config, metas Metas, ctxName string, set map[string]string, remove []string, overwrite bool) error {
+	m := metas.Get(ctxName)
+	if m == nil {
+		if legacy, err := legacyMeta(kc, ctxName); err == nil {
+			m = legacy
+		} else {
+			m = &Meta{}
+		}
+	}
+
+	for k, v := range set {
+		if old, ok := m.Labels[k]; ok && old != v && !overwrite {
+			return errors.Wrapf(ErrLabelExists, "context: %v, label: %v=%v", ctxName, k, old)
+		}
+	}
+
+	if m.Labels == nil {
+		m.Labels = make(map[string]string)
+	}
+	for k, v := range set {
+		m.Labels[k] = v
+	}
+	for _, k := range remove {
+		delete(m.Labels, k)
+	}
+	if len(m.Labels) == 0 {
+		m.Labels = nil
+	}
+
+	metas[ctxName] = m
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLabelChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		hasErr bool
+		set    map[string]string
+		remove []string
+	}{
+		{"set", []string{"env=prod", "team=payments"}, false,
+			map[string]string{"env": "prod", "team": "payments"}, nil},
+		{"remove", []string{"env-"}, false, map[string]string{}, []string{"env"}},
+		{"empty value", []string{"env="}, false, map[string]string{"env": ""}, nil},
+		{"no value", []string{"env"}, true, nil, nil},
+		{"invalid key", []string{"e nv=prod"}, true, nil, nil},
+		{"invalid value", []string{"env=a b"}, true, nil, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			set, remove, err := ParseLabelChanges(test.args)
+			assert.Equal(test.hasErr, err != nil)
+			assert.Equal(test.set, set)
+			assert.Equal(test.remove, remove)
+		})
+	}
+}
+
+func TestSelectContexts(t *testing.T) {
+	kc := newTestConfig("prod-eu", "https://kubernetes:7001")
+	kc.Contexts["prod-us"] = kc.Contexts["prod-eu"]
+	kc.Contexts["preprod"] = kc.Contexts["prod-eu"]
+
+	metas := Metas{
+		"prod-eu": &Meta{Labels: map[string]string{"env": "prod", "region": "eu"}},
+		"prod-us": &Meta{Labels: map[string]string{"env": "prod", "region": "us"}},
+		"preprod": &Meta{Labels: map[string]string{"env": "preprod"}},
+	}
+
+	tests := []struct {
+		selector string
+		expected []string
+	}{
+		{"", []string{"preprod", "prod-eu", "prod-us"}},
+		{"env=prod", []string{"prod-eu", "prod-us"}},
+		{"env=prod,region!=us", []string{"prod-eu"}},
+		{"region", []string{"prod-eu", "prod-us"}},
+		{"!region", []string{"preprod"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.selector, func(t *testing.T) {
+			assert := assert.New(t)
+
+			sel, err := ParseSelector(test.selector)
+			assert.Nil(err)
+
+			var names []string
+			for k := range SelectContexts(kc.Contexts, metas, sel) {
+				names = append(names, k)
+			}
+			assert.ElementsMatch(test.expected, names)
+		})
+	}
+
+	_, err := ParseSelector("env=(prod")
+	assert.True(t, errors.Is(err, ErrInvalidSelector))
+}
+
+func TestApplyLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	ctxName := "kubernetes-admin@172.31.7.182:6443-qa"
+	kc := newTestConfig(ctxName, "https://kubernetes:7001")
+	metas := Metas{}
+
+	// metadata of legacy context is parsed from context name.
+	err := ApplyLabels(kc, metas, ctxName, map[string]string{"env": "qa", "team": "a"}, nil, false)
+	assert.Nil(err)
+	assert.Equal("172.31.7.182", metas[ctxName].RemoteHost)
+	assert.Equal(map[string]string{"env": "qa", "team": "a"}, metas[ctxName].Labels)
+
+	err = ApplyLabels(kc, metas, ctxName, map[string]string{"env": "prod"}, nil, false)
+	assert.True(errors.Is(err, ErrLabelExists))
+
+	err = ApplyLabels(kc, metas, ctxName, map[string]string{"env": "prod"}, []string{"team"}, true)
+	assert.Nil(err)
+	assert.Equal(map[string]string{"env": "prod"}, metas[ctxName].Labels)
+
+	err = ApplyLabels(kc, metas, ctxName, nil, []string{"env"}, false)
+	assert.Nil(err)
+	assert.Nil(metas[ctxName].Labels)
+}
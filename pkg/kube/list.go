@@ -2,13 +2,17 @@ package kube
 
 import (
 	"fmt"
-	"log"
+	"net/url"
+	"os"
 	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
-	"github.com/shohi/cube/pkg/base"
+	"k8s.io/apimachinery/pkg/labels"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/matcher"
 )
 
 var (
@@ -16,15 +20,56 @@ var (
 	errLocalPortNotFound        = errors.New("cube: local port not found")
 	errLocalServerNotKubernetes = errors.New("cube: local server not 'kubernetes'")
 	errNoAddrInContextName      = errors.New("cube: no addr in context name")
+	errInvalidServer            = errors.New("cube: invalid server address")
 )
 
 const (
 	defaultRemoteAPIPort = 6443
+
+	certExpiryLayout = "2006-01-02"
+)
+
+// Tunnel status
+const (
+	TunnelUp   = "up"
+	TunnelDown = "down"
+)
+
+// Cluster types
+const (
+	TypeCubeTunnel = "cube-tunnel" // managed by cube, accessed via ssh tunnel
+	TypeDirect     = "direct"
+	TypeKind       = "kind"
+	TypeMinikube   = "minikube"
+	TypeExecAuth   = "exec-auth"
+)
+
+// Auth types
+const (
+	AuthExec       = "exec"
+	AuthProvider   = "auth-provider"
+	AuthClientCert = "client-cert"
+	AuthToken      = "token"
+	AuthBasic      = "basic"
+	AuthNone       = "none"
 )
 
 type ClusterInfo struct {
 	Name       string `json:"name"`
 	SSHForward string `json:"sshForward"`
+
+	Type          string `json:"type"`
+	Context       string `json:"context"`
+	Server        string `json:"server,omitempty"`
+	LocalPort     int    `json:"localPort"`
+	RemoteAPIAddr string `json:"remoteAPIAddr,omitempty"`
+	JumpHost      string `json:"jumpHost,omitempty"`
+	AuthType      string `json:"authType,omitempty"`
+	Current       bool   `json:"current"`
+	Tunnel        string `json:"tunnel,omitempty"`
+	CertExpiry    string `json:"certExpiry,omitempty"` // earliest expiry of CA and client certs
+
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 func (f ClusterInfo) String() string {
@@ -55,32 +100,87 @@ func (c ClusterInfos) Swap(i, j int) {
 	c[i], c[j] = c[j], c[i]
 }
 
-func ListAllClusters() (ClusterInfos, error) {
-	kc, err := Load(base.GetLocalKubePath())
+// ListWarning reports a context which can't be listed.
+type ListWarning struct {
+	Context string `json:"context"`
+	Reason  string `json:"reason"`
+}
+
+func (w ListWarning) String() string {
+	return fmt.Sprintf("context=%q reason=%q", w.Context, w.Reason)
+}
+
+type ListWarnings []ListWarning
+
+// ListAllClusters lists all contexts in kubeconfig, both managed by cube and
+// not. Broken entries are reported as warnings.
+func ListAllClusters() (ClusterInfos, ListWarnings, error) {
+	return ListClusters(NewStore(), base.DefaultState(), "")
+}
+
+// ListClusters lists all contexts in kubeconfig of given store, with
+// metadata under given state. via is the ssh jump server, SSH_VIA env is
+// used if empty.
+func ListClusters(store *Store, state base.State, via string) (ClusterInfos, ListWarnings, error) {
+	kc, err := store.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metas, err := LoadMetasFrom(state.MetaPath())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	ret, warnings := genClusterInfos(kc, metas, via)
+	for k := range ret {
+		if ret[k].Type == TypeCubeTunnel {
+			ret[k].Tunnel = tunnelStatus(ret[k].LocalPort)
+		}
 	}
 
+	return ret, warnings, nil
+}
+
+func genClusterInfos(kc *clientcmdapi.Config, metas Metas, via string) (ClusterInfos, ListWarnings) {
 	var ret ClusterInfos
+	var warnings ListWarnings
 
 	for k := range kc.Contexts {
-		info, err := ParseContext(kc, k)
-		if err == nil {
-			ret = append(ret, *info)
-			continue
+		info, err := ParseContextVia(kc, metas, k, via)
+		if errors.Is(err, errLocalServerNotKubernetes) {
+			info, err = parseUnmanagedContext(kc, k)
 		}
 
-		if err != nil && !errors.Is(err, errLocalServerNotKubernetes) {
-			log.Println(err)
+		if err != nil {
+			warnings = append(warnings, ListWarning{Context: k, Reason: err.Error()})
+			continue
 		}
+
+		info.Labels = metas.Labels(k)
+		ret = append(ret, *info)
 	}
 
 	sort.Sort(ret)
+	sort.Slice(warnings, func(i, j int) bool { return warnings[i].Context < warnings[j].Context })
 
-	return ret, nil
+	return ret, warnings
 }
 
-func ParseContext(kc *clientcmdapi.Config, ctxName string) (*ClusterInfo, error) {
+// ParseContext extracts cluster info for given context. Cube metadata is
+// preferred, and context name is parsed only for clusters merged by cube
+// before metadata is recorded.
+func ParseContext(kc *clientcmdapi.Config, metas Metas, ctxName string) (*ClusterInfo, error) {
+	return ParseContextVia(kc, metas, ctxName, "")
+}
+
+// ParseContextVia is ParseContext with given ssh jump server, SSH_VIA env
+// is used if empty.
+func ParseContextVia(kc *clientcmdapi.Config, metas Metas, ctxName, via string) (*ClusterInfo, error) {
+	if via == "" {
+		via = os.Getenv("SSH_VIA")
+	}
+
 	ctx := kc.Contexts[ctxName]
 	cluster, ok := kc.Clusters[ctx.Cluster]
 	if !ok {
@@ -96,15 +196,89 @@ func ParseContext(kc *clientcmdapi.Config, ctxName string) (*ClusterInfo, error)
 		return nil, errors.Wrapf(errLocalServerNotKubernetes, "ctx: %v", ctxName)
 	}
 
-	info := genClusterInfo(ctxName, p)
+	var info ClusterInfo
+	if meta := metas.Get(ctxName); meta != nil && meta.RemoteHost != "" {
+		info = genClusterInfoFromMeta(meta, p, via)
+	} else {
+		info = genClusterInfo(ctxName, p, via)
+	}
+
 	if info.SSHForward == "" {
 		return nil, errors.Wrapf(errNoAddrInContextName, "ctx: %v", ctxName)
 	}
 
+	info.Type = TypeCubeTunnel
+	info.Context = ctxName
+	info.Server = cluster.Server
+	info.LocalPort = p
+	info.JumpHost = via
+	info.AuthType = authType(kc.AuthInfos[ctx.AuthInfo])
+	info.Current = kc.CurrentContext == ctxName
+
+	if expiry, ok := EarliestExpiry(kc, ctxName); ok {
+		info.CertExpiry = expiry.Format(certExpiryLayout)
+	}
+
 	return &info, nil
 }
 
-func genClusterInfo(kctx string, port int) ClusterInfo {
+// parseUnmanagedContext extracts cluster info for context not managed by cube.
+func parseUnmanagedContext(kc *clientcmdapi.Config, ctxName string) (*ClusterInfo, error) {
+	ctx := kc.Contexts[ctxName]
+	cluster, ok := kc.Clusters[ctx.Cluster]
+	if !ok {
+		return nil, errors.Wrapf(errClusterNotFound, "ctx: %v", ctxName)
+	}
+
+	u, err := url.Parse(cluster.Server)
+	if err != nil {
+		return nil, errors.Wrapf(errInvalidServer, "ctx: %v, error %v", ctxName, err)
+	}
+
+	user := kc.AuthInfos[ctx.AuthInfo]
+	info := ClusterInfo{
+		Name:          ctxName,
+		Type:          unmanagedType(ctxName, ctx, user),
+		Context:       ctxName,
+		Server:        cluster.Server,
+		RemoteAPIAddr: u.Host,
+		AuthType:      authType(user),
+		Current:       kc.CurrentContext == ctxName,
+	}
+
+	if expiry, ok := EarliestExpiry(kc, ctxName); ok {
+		info.CertExpiry = expiry.Format(certExpiryLayout)
+	}
+
+	return &info, nil
+}
+
+// unmanagedType guesses type of cluster not managed by cube, by well-known
+// names of local clusters and auth method.
+func unmanagedType(ctxName string, ctx *clientcmdapi.Context, user *clientcmdapi.AuthInfo) string {
+	switch {
+	case strings.HasPrefix(ctxName, "kind-") || strings.HasPrefix(ctx.Cluster, "kind-"):
+		return TypeKind
+	case ctxName == "minikube" || ctx.Cluster == "minikube":
+		return TypeMinikube
+	case user != nil && (user.Exec != nil || user.AuthProvider != nil):
+		return TypeExecAuth
+	default:
+		return TypeDirect
+	}
+}
+
+func genClusterInfoFromMeta(meta *Meta, port int, via string) ClusterInfo {
+	return ClusterInfo{
+		Name:          fmt.Sprintf("%v-%v", meta.RemoteHost, meta.NameSuffix),
+		SSHForward:    GetPortForwardingCmd(port, meta.RemoteAPIAddr(), via),
+		RemoteAPIAddr: meta.RemoteAPIAddr(),
+	}
+}
+
+// genClusterInfo parses cluster info from context name in legacy format,
+// i.e. `kubernetes-admin@172.31.7.182:6443-test`.
+func genClusterInfo(kctx string, port int, via string) ClusterInfo {
 	info := ClusterInfo{
 		Name: getShortContext(kctx),
 	}
@@ -115,6 +289,96 @@ func genClusterInfo(kctx string, port int) ClusterInfo {
 	}
 
 	// TODO: dynamicially get real remote port by parsing related kube config file.
-	info.SSHForward = GetPortForwardingCmd(port, h, "")
+	info.SSHForward = GetPortForwardingCmd(port, h, via)
+	info.RemoteAPIAddr = h
 	return info
 }
+
+// authType returns how the user authenticates to cluster.
+func authType(u *clientcmdapi.AuthInfo) string {
+	switch {
+	case u == nil:
+		return AuthNone
+	case u.Exec != nil:
+		return AuthExec
+	case u.AuthProvider != nil:
+		return AuthProvider
+	case len(u.ClientCertificateData) > 0 || u.ClientCertificate != "":
+		return AuthClientCert
+	case u.Token != "" || u.TokenFile != "":
+		return AuthToken
+	case u.Username != "" || u.Password != "":
+		return AuthBasic
+	default:
+		return AuthNone
+	}
+}
+
+// FilterClusters returns clusters matching all filters.
+func FilterClusters(s ClusterInfos, fns ...FilterFunc) ClusterInfos {
+	result := make(ClusterInfos, 0, len(s))
+
+	for _, c := range s {
+		matched := true
+		for _, fn := range fns {
+			if !fn(c) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
+type FilterFunc func(ClusterInfo) bool
+
+func EnableAll(_ ClusterInfo) bool {
+	return true
+}
+
+// ByName filters clusters by short name or context name.
+func ByName(m matcher.Matcher) FilterFunc {
+	return func(c ClusterInfo) bool {
+		return matcher.Any(m, c.Name, c.Context)
+	}
+}
+
+// BySelector filters clusters by label selector.
+func BySelector(sel labels.Selector) FilterFunc {
+	if sel == nil || sel.Empty() {
+		return EnableAll
+	}
+
+	return func(c ClusterInfo) bool {
+		return sel.Matches(labels.Set(c.Labels))
+	}
+}
+
+// ByManaged filters clusters by whether they're managed by cube.
+func ByManaged(managed, unmanaged bool) FilterFunc {
+	switch {
+	case managed:
+		return func(c ClusterInfo) bool { return c.Type == TypeCubeTunnel }
+	case unmanaged:
+		return func(c ClusterInfo) bool { return c.Type != TypeCubeTunnel }
+	default:
+		return EnableAll
+	}
+}
+
+// portListening checks whether the port is listening on localhost, replaceable in tests.
+var portListening = base.IsListening
+
+// tunnelStatus checks whether local forwarding port is listening.
+func tunnelStatus(port int) string {
+	if portListening(port) {
+		return TunnelUp
+	}
+
+	return TunnelDown
+}
@@ -0,0 +1,86 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+func TestGenClusterInfos(t *testing.T) {
+	assert := assert.New(t)
+
+	kc := newTestConfig("kubernetes-admin@172.31.7.182:6443-qa", "https://kubernetes:7001")
+	add := func(name, server string) {
+		other := newTestConfig(name, server)
+		kc.Clusters[name] = other.Clusters[name]
+		kc.AuthInfos[name] = other.AuthInfos[name]
+		kc.Contexts[name] = other.Contexts[name]
+	}
+
+	add("kind-dev", "https://127.0.0.1:40000")
+	add("minikube", "https://192.168.64.2:8443")
+	add("gke", "https://35.1.1.1")
+	kc.AuthInfos["gke"].Exec = &clientcmdapi.ExecConfig{Command: "gcloud"}
+	add("prod", "https://10.0.0.1:6443")
+	add("broken", "https://kubernetes:7002")
+	kc.Contexts["dangling"] = &clientcmdapi.Context{Cluster: "missing"}
+	kc.CurrentContext = "prod"
+
+	infos, warnings := genClusterInfos(kc, Metas{}, "")
+
+	types := make(map[string]string)
+	for _, info := range infos {
+		types[info.Context] = info.Type
+	}
+
+	assert.Equal(map[string]string{
+		"kubernetes-admin@172.31.7.182:6443-qa": TypeCubeTunnel,
+		"kind-dev":                              TypeKind,
+		"minikube":                              TypeMinikube,
+		"gke":                                   TypeExecAuth,
+		"prod":                                  TypeDirect,
+	}, types)
+
+	for _, info := range infos {
+		if info.Context == "prod" {
+			assert.True(info.Current)
+			assert.Equal("10.0.0.1:6443", info.RemoteAPIAddr)
+			assert.Equal(AuthToken, info.AuthType)
+		}
+	}
+
+	assert.Len(warnings, 2)
+	assert.Equal("broken", warnings[0].Context)
+	assert.Equal("dangling", warnings[1].Context)
+}
+
+func TestFilterClusters_managed(t *testing.T) {
+	infos := ClusterInfos{
+		{Name: "qa", Type: TypeCubeTunnel},
+		{Name: "kind-dev", Type: TypeKind},
+	}
+
+	tests := []struct {
+		name      string
+		managed   bool
+		unmanaged bool
+		expected  []string
+	}{
+		{"all", false, false, []string{"qa", "kind-dev"}},
+		{"managed", true, false, []string{"qa"}},
+		{"unmanaged", false, true, []string{"kind-dev"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var names []string
+			for _, c := range FilterClusters(infos, ByName(matcher.All), ByManaged(test.managed, test.unmanaged)) {
+				names = append(names, c.Name)
+			}
+			assert.Equal(t, test.expected, names)
+		})
+	}
+}
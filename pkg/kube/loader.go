@@ -0,0 +1,239 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+// ErrKubeConfigNotFound is returned when the file given by `--kubeconfig`
+// doesn't exist.
+var ErrKubeConfigNotFound = errors.New("cube: kubeconfig file not found")
+
+// configAccess is where local kubeconfig is read from and written to.
+// It follows clientcmd loading rules, and optionally redirects new
+// entries to a target file.
+type configAccess struct {
+	*clientcmd.ClientConfigLoadingRules
+
+	target string
+}
+
+// GetDefaultFilename implements clientcmd.ConfigAccess
+func (c *configAccess) GetDefaultFilename() string {
+	if c.target != "" {
+		return c.target
+	}
+
+	return c.ClientConfigLoadingRules.GetDefaultFilename()
+}
+
+// GetLoadingPrecedence implements clientcmd.ConfigAccess.
+// The target file is included so that it's locked when modified.
+func (c *configAccess) GetLoadingPrecedence() []string {
+	precedence := c.ClientConfigLoadingRules.GetLoadingPrecedence()
+	if c.target == "" {
+		return precedence
+	}
+
+	for _, p := range precedence {
+		if p == c.target {
+			return precedence
+		}
+	}
+
+	return append(append([]string{}, precedence...), c.target)
+}
+
+// Store reads and writes local kubeconfig, which may be a single file,
+// a list of files or a dir holding one file per managed cluster.
+type Store struct {
+	access   *configAccess
+	split    bool
+	splitDir string
+	explicit string // file given by `--kubeconfig`, which must exist
+}
+
+// StoreOptions locate local kubeconfig.
+type StoreOptions struct {
+	// KubeConfig is the kubeconfig file, as `--kubeconfig`. If not set,
+	// KUBECONFIG env or the default path is used.
+	KubeConfig string
+
+	// Target is the file new entries are written to, as `--kubeconfig-target`.
+	Target string
+
+	// Split stores each managed cluster in its own file under SplitDir.
+	Split bool
+
+	// SplitDir is dir of per-cluster files. If not set, `~/.kube/cube.d` is used.
+	SplitDir string
+}
+
+// NewStore creates a Store for local kubeconfig based on global settings.
+func NewStore() *Store {
+	return NewStoreWith(StoreOptions{
+		KubeConfig: base.KubeConfigFlag,
+		Target:     base.KubeConfigTarget,
+		Split:      base.IsSplitStorage(),
+	})
+}
+
+// NewStoreWith creates a Store for local kubeconfig with given options.
+func NewStoreWith(opts StoreOptions) *Store {
+	s := &Store{
+		split:    opts.Split,
+		splitDir: base.GetSplitDir(),
+	}
+	if opts.SplitDir != "" {
+		s.splitDir = base.ExpandPath(opts.SplitDir)
+	}
+	if opts.KubeConfig != "" {
+		s.explicit = base.ExpandPath(opts.KubeConfig)
+	}
+
+	var target string
+	if opts.Target != "" {
+		target = base.ExpandPath(opts.Target)
+	}
+
+	rules := &clientcmd.ClientConfigLoadingRules{
+		Precedence: s.Paths(),
+	}
+
+	s.access = &configAccess{
+		ClientConfigLoadingRules: rules,
+		target:                   target,
+	}
+
+	return s
+}
+
+// Paths returns local kubeconfig files in loading order. Files in
+// split dir are always included, so that managed clusters are visible
+// regardless of KUBECONFIG.
+func (s *Store) Paths() []string {
+	return mergePaths(base.LocalKubePaths(s.explicit), base.SplitPaths(s.splitDir))
+}
+
+// Load reads local kubeconfig. Multiple files are merged following
+// clientcmd loading rules. Missing files are ignored, except the one
+// given by `--kubeconfig`, as kubectl does.
+//
+// The file isn't set as ExplicitPath of loading rules, since then files
+// in split dir would be left out.
+func (s *Store) Load() (*clientcmdapi.Config, error) {
+	if s.explicit != "" {
+		if _, err := os.Stat(s.explicit); os.IsNotExist(err) {
+			return nil, errors.Wrapf(ErrKubeConfigNotFound, "path: %v", s.explicit)
+		}
+	}
+
+	return s.access.ClientConfigLoadingRules.Load()
+}
+
+// Locate returns file where entries for new cluster with given name suffix
+// should be written to. Empty means the default destination.
+func (s *Store) Locate(nameSuffix string) string {
+	if !s.split {
+		return ""
+	}
+
+	return base.SplitPath(s.splitDir, nameSuffix)
+}
+
+// Save writes kubeconfig back to local files. Every entry goes to the
+// file where it's loaded from, new entries go to the located file if set,
+// then the target file if given, otherwise the first existing file
+// in loading order. Files in split dir left without any context are removed.
+func (s *Store) Save(kc *clientcmdapi.Config) error {
+	if err := clientcmd.ModifyConfig(s.access, *kc, false); err != nil {
+		return err
+	}
+
+	return s.removeEmptySplitFiles()
+}
+
+func (s *Store) removeEmptySplitFiles() error {
+	for _, p := range base.SplitPaths(s.splitDir) {
+		kc, err := Load(p)
+		if err != nil {
+			return err
+		}
+
+		if len(kc.Contexts) > 0 {
+			continue
+		}
+
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setLocation sets the file where the entries for given context are stored.
+func setLocation(kc *clientcmdapi.Config, ctxName, location string) {
+	if location == "" {
+		return
+	}
+
+	ctx, ok := kc.Contexts[ctxName]
+	if !ok {
+		return
+	}
+
+	ctx.LocationOfOrigin = location
+	if c, ok := kc.Clusters[ctx.Cluster]; ok {
+		c.LocationOfOrigin = location
+	}
+	if u, ok := kc.AuthInfos[ctx.AuthInfo]; ok {
+		u.LocationOfOrigin = location
+	}
+}
+
+func mergePaths(lists ...[]string) []string {
+	var ret []string
+	seen := make(map[string]bool)
+
+	for _, l := range lists {
+		for _, p := range l {
+			key := filepath.Clean(p)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			ret = append(ret, p)
+		}
+	}
+
+	return ret
+}
+
+// LoadLocal reads local kubeconfig.
+func LoadLocal() (*clientcmdapi.Config, error) {
+	return NewStore().Load()
+}
+
+// WriteLocal writes kubeconfig back to local files.
+func WriteLocal(kc *clientcmdapi.Config) error {
+	return NewStore().Save(kc)
+}
+
+// LocalPaths returns local kubeconfig files in loading order.
+func LocalPaths() []string {
+	return NewStore().Paths()
+}
+
+// SplitKubeConfigEnv returns the value of KUBECONFIG which covers local
+// kubeconfig files as well as all per-cluster files in split dir.
+func SplitKubeConfigEnv() string {
+	return strings.Join(LocalPaths(), string(filepath.ListSeparator))
+}
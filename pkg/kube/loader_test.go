@@ -0,0 +1,173 @@
+package kube
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+func newTestConfig(name, server string) *clientcmdapi.Config {
+	kc := clientcmdapi.NewConfig()
+	kc.Clusters[name] = &clientcmdapi.Cluster{Server: server}
+	kc.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: "token-" + name}
+	kc.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+
+	return kc
+}
+
+func setupKubeConfigFiles(t *testing.T, names ...string) (string, []string) {
+	dir, err := ioutil.TempDir("", "cube-loader")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, name := range names {
+		p := filepath.Join(dir, name+".yaml")
+		if err := WriteToFile(newTestConfig(name, "https://"+name+":6443"), p); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+
+	return dir, paths
+}
+
+func TestLoader_LoadAndWriteLocal(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, paths := setupKubeConfigFiles(t, "a", "b")
+	defer os.RemoveAll(dir)
+
+	os.Setenv(base.KubeConfigEnv, paths[0]+string(filepath.ListSeparator)+paths[1])
+	defer os.Unsetenv(base.KubeConfigEnv)
+
+	kc, err := LoadLocal()
+	assert.Nil(err)
+	assert.Len(kc.Contexts, 2)
+
+	// new entries go to first file, deleted entries are removed from owner.
+	added := newTestConfig("c", "https://c:6443")
+	kc.Clusters["c"] = added.Clusters["c"]
+	kc.AuthInfos["c"] = added.AuthInfos["c"]
+	kc.Contexts["c"] = added.Contexts["c"]
+
+	delete(kc.Clusters, "b")
+	delete(kc.AuthInfos, "b")
+	delete(kc.Contexts, "b")
+
+	assert.Nil(WriteLocal(kc))
+
+	first, err := Load(paths[0])
+	assert.Nil(err)
+	assert.Contains(first.Contexts, "a")
+	assert.Contains(first.Contexts, "c")
+
+	second, err := Load(paths[1])
+	assert.Nil(err)
+	assert.Empty(second.Contexts)
+	assert.Empty(second.Clusters)
+}
+
+func TestLoader_WriteLocalToTarget(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, paths := setupKubeConfigFiles(t, "a")
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "target.yaml")
+	base.KubeConfigFlag = paths[0]
+	base.KubeConfigTarget = target
+	defer func() {
+		base.KubeConfigFlag = ""
+		base.KubeConfigTarget = ""
+	}()
+
+	kc, err := LoadLocal()
+	assert.Nil(err)
+
+	added := newTestConfig("c", "https://c:6443")
+	kc.Clusters["c"] = added.Clusters["c"]
+	kc.AuthInfos["c"] = added.AuthInfos["c"]
+	kc.Contexts["c"] = added.Contexts["c"]
+
+	assert.Nil(WriteLocal(kc))
+
+	tkc, err := Load(target)
+	assert.Nil(err)
+	assert.Contains(tkc.Contexts, "c")
+	assert.NotContains(tkc.Contexts, "a")
+}
+
+func TestStore_SplitMode(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, paths := setupKubeConfigFiles(t, "main")
+	defer os.RemoveAll(dir)
+
+	base.KubeConfigFlag = paths[0]
+	base.SplitKubeConfigDir = filepath.Join(dir, "cube.d")
+	base.StorageMode = base.StorageSplit
+	defer func() {
+		base.KubeConfigFlag = ""
+		base.SplitKubeConfigDir = "~/.kube/cube.d"
+		base.StorageMode = base.StorageFile
+	}()
+
+	s := NewStore()
+	kc, err := s.Load()
+	assert.Nil(err)
+
+	added := newTestConfig("c", "https://c:6443")
+	kc.Clusters["c"] = added.Clusters["c"]
+	kc.AuthInfos["c"] = added.AuthInfos["c"]
+	kc.Contexts["c"] = added.Contexts["c"]
+	setLocation(kc, "c", s.Locate("qa"))
+
+	assert.Nil(s.Save(kc))
+
+	splitPath := filepath.Join(dir, "cube.d", "qa.yaml")
+	skc, err := Load(splitPath)
+	assert.Nil(err)
+	assert.Contains(skc.Contexts, "c")
+
+	mkc, err := Load(paths[0])
+	assert.Nil(err)
+	assert.NotContains(mkc.Contexts, "c")
+
+	// split files are visible without KUBECONFIG and removed once empty.
+	s = NewStore()
+	assert.Equal([]string{paths[0], splitPath}, s.Paths())
+
+	kc, err = s.Load()
+	assert.Nil(err)
+	assert.Contains(kc.Contexts, "c")
+
+	delete(kc.Clusters, "c")
+	delete(kc.AuthInfos, "c")
+	delete(kc.Contexts, "c")
+	assert.Nil(s.Save(kc))
+
+	exist, _ := base.FileExists(splitPath)
+	assert.False(exist)
+}
+
+func TestStore_MissingExplicitFile(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := setupKubeConfigFiles(t)
+	defer os.RemoveAll(dir)
+
+	base.KubeConfigFlag = filepath.Join(dir, "typo.yaml")
+	defer func() { base.KubeConfigFlag = "" }()
+
+	_, err := NewStore().Load()
+	assert.Equal(ErrKubeConfigNotFound, errors.Cause(err))
+}
@@ -2,16 +2,20 @@ package kube
 
 import (
 	"fmt"
+	"io"
+	"os"
 
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/pkg/errors"
 	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/log"
 )
 
 var (
 	ErrEmptyNameSuffix      = errors.New("cube: empty name-suffix for merge")
 	ErrConfigAlreadyMerged  = errors.New("cube: kubeconfig already merged")
+	ErrCredentialsRotated   = errors.New("cube: kubeconfig already merged with rotated credentials")
 	ErrClusterAlreadyExists = errors.New("cube: cluster already exists")
 	ErrContextAlreadyExists = errors.New("cube: context already exists")
 	ErrUserAlreadyExists    = errors.New("cube: user already exists")
@@ -24,12 +28,45 @@ type MergeOptions struct {
 	NameSuffix string
 	LocalPort  int
 	Force      bool
+
+	// Naming is templates for local names. If not set, DefaultNaming is used.
+	Naming Naming
+
+	// OnConflict is how name conflicts are resolved. If not set, ConflictFail is used.
+	OnConflict ConflictStrategy
+
+	// PortRange is where local port is allocated from. If not set, DefaultPortRange is used.
+	PortRange PortRange
+
+	// Labels are attached to merged cluster, e.g. env=prod.
+	Labels map[string]string
+
+	// Transport fetches remote kubeconfig and certs. If not set, SCPTransport is used.
+	Transport Transport
+
+	// Store is where kubeconfig is loaded and saved. If not set, NewStore is used.
+	Store *Store
+
+	// StateDir is where metadata, certs and caches are kept. If not set,
+	// base.StateDir is used.
+	StateDir string
+
+	// SSHVia is the ssh jump server, e.g. user@jump. If not set, SSH_VIA env is used.
+	SSHVia string
+
+	// Stderr receives output of ssh tunnels, discarded if nil.
+	Stderr io.Writer
+
+	// Logger receives warnings. If not set, log.Default is used.
+	Logger *log.Logger
 }
 
 // Merger merge remote cluster config into local `~/.kube/config`
 type Merger interface {
 	Merge() error
+	Save() error
 	Result() *clientcmdapi.Config
+	Summary() MergeSummary
 	LocalPort() int
 	RemoteAPIAddr() string
 }
@@ -38,6 +75,9 @@ type merger struct {
 	opts MergeOptions
 
 	d         *Downloader
+	store     *Store
+	state     base.State
+	log       *log.Logger
 	localPort int
 
 	mainKC *clientcmdapi.Config
@@ -45,35 +85,59 @@ type merger struct {
 	inKC          *clientcmdapi.Config
 	inClusterName string
 	inCK          ClusterKeyInfo
+	inMeta        *Meta
+
+	metas    Metas
+	identity identityMatch
+	summary  MergeSummary
 
 	updatedClusterName string
 }
 
 func NewMerger(opts MergeOptions) Merger {
-	d := NewDownloader(opts.RemoteAddr)
+	if opts.Store == nil {
+		opts.Store = NewStore()
+	}
+	if opts.SSHVia == "" {
+		opts.SSHVia = os.Getenv("SSH_VIA")
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+
+	state := base.NewState(opts.StateDir)
+	d := newDownloader(opts.RemoteAddr, opts.Transport, state, opts.Logger)
+	if opts.Naming == (Naming{}) {
+		opts.Naming = DefaultNaming()
+	}
+	if opts.OnConflict == "" {
+		opts.OnConflict = ConflictFail
+	}
+	if opts.PortRange == (PortRange{}) {
+		opts.PortRange = defaultPortRange(opts.Logger)
+	}
 
 	m := &merger{
 		opts:      opts,
 		localPort: opts.LocalPort,
 		d:         d,
+		store:     opts.Store,
+		state:     state,
+		log:       opts.Logger,
 	}
 
 	return m
 }
 
 func (m *merger) loadMainKC() error {
-	configPath := base.GetLocalKubePath()
-	exist, isDir := base.FileExists(configPath)
-
-	if !exist {
-		m.mainKC = clientcmdapi.NewConfig()
-		return nil
-	} else if isDir {
-		return fmt.Errorf("config path [%v] is a dir, not file", configPath)
+	for _, configPath := range m.store.Paths() {
+		if exist, isDir := base.FileExists(configPath); exist && isDir {
+			return fmt.Errorf("config path [%v] is a dir, not file", configPath)
+		}
 	}
 
 	var err error
-	m.mainKC, err = Load(base.GetLocalKubePath())
+	m.mainKC, err = m.store.Load()
 	if err != nil {
 		return err
 	}
@@ -106,78 +170,303 @@ func (m *merger) Merge() error {
 	return nil
 }
 
+// Save writes merged kubeconfig to local store, and records metadata
+// for the merged cluster.
+func (m *merger) Save() error {
+	if m.summary.Result == MergeSkipped {
+		return nil
+	}
+
+	if err := m.store.Save(m.mainKC); err != nil {
+		return err
+	}
+
+	metas, err := LoadMetasFrom(m.state.MetaPath())
+	if err != nil {
+		return err
+	}
+	metas[m.inCK.CtxName] = m.inMeta
+
+	return metas.SaveTo(m.state.MetaPath())
+}
+
 func (m *merger) LocalPort() int {
 	return m.localPort
 }
 
 func (m *merger) RemoteAPIAddr() string {
-	return genRemoteAPIAddr(m.opts.RemoteAddr, m.inCK.Cluster.Server)
+	return m.inMeta.RemoteAPIAddr()
+}
+
+func (m *merger) Summary() MergeSummary {
+	return m.summary
 }
 
 func (m *merger) Result() *clientcmdapi.Config {
 	return m.mainKC
 }
 
-func (m *merger) checkLocalPort() error {
+// checkLocalPort allocates local port if not given, otherwise checks that
+// the port isn't used by clusters other than the one with given names.
+func (m *merger) checkLocalPort(names Names) error {
 	if m.localPort == 0 {
-		nextPort := getNextLocalPort(m.mainKC)
-		m.localPort = nextPort
+		p, err := getNextLocalPort(m.mainKC, m.metas, m.opts.PortRange, m.log)
+		if err != nil {
+			return err
+		}
+
+		m.localPort = p
+		return nil
 	}
 
 	if m.localPort <= 0 || m.localPort > 65535 {
 		return errors.Wrapf(ErrInvalidLocalPort, "port: %v", m.localPort)
 	}
 
+	if owner := m.portOwner(m.localPort, names); owner != "" {
+		return errors.Wrapf(ErrLocalPortInUse, "port: %v, owner: %v", m.localPort, owner)
+	}
+
 	return nil
 }
 
-func (m *merger) checkExists() error {
-	cluster, found := findCluster(m.mainKC, m.inCK.Cluster)
-	if found && !m.opts.Force {
-		return errors.Wrapf(ErrConfigAlreadyMerged, "cluster: [%v]", cluster)
+// portOwner returns the context which uses or reserves the port, except
+// the one with given names.
+func (m *merger) portOwner(port int, names Names) string {
+	for k, ctx := range m.mainKC.Contexts {
+		if k == names.Context || ctx.Cluster == names.Cluster {
+			continue
+		}
+
+		c, ok := m.mainKC.Clusters[ctx.Cluster]
+		if !ok {
+			continue
+		}
+
+		if h, p, err := GetOccupiedLocalPort(c.Server); err == nil && h == defaultHost && p == port {
+			return k
+		}
 	}
 
-	return nil
+	for k, meta := range m.metas {
+		if _, ok := m.mainKC.Contexts[k]; ok || k == names.Context {
+			continue
+		}
+
+		if meta != nil && meta.LocalPort == port {
+			return k
+		}
+	}
+
+	return ""
 }
 
 func (m *merger) doMerge() error {
-	if err := m.checkExists(); err != nil {
+	suffix := m.opts.NameSuffix
+	names, err := m.renderNames(suffix)
+	if err != nil {
 		return err
 	}
 
-	if err := m.checkLocalPort(); err != nil {
+	metas, err := LoadMetasFrom(m.state.MetaPath())
+	if err != nil {
 		return err
 	}
+	m.metas = metas
+
+	m.identity = identify(m.mainKC, metas, m.inCK, m.inMeta, m.opts.SSHVia, m.opts.Stderr)
+	m.summary = MergeSummary{
+		Strategy: m.opts.OnConflict,
+		Result:   MergeAdded,
+		Identity: m.identity.Identity,
+		Related:  m.identity.Context,
+	}
+
+	switch m.identity.Identity {
+	case IdentityRotated:
+		// same cluster, update credentials in place or leave them.
+		if m.opts.Force {
+			return m.rotate(metas.Get(m.identity.Context), MergeRotated)
+		}
+
+		switch m.opts.OnConflict {
+		case ConflictReplace:
+			return m.rotate(metas.Get(m.identity.Context), MergeRotated)
+		case ConflictSkip:
+			m.skip(m.existingNames(m.identity.Context), m.opts.NameSuffix)
+			return nil
+		default:
+			return errors.Wrapf(ErrCredentialsRotated, "context: [%v]", m.identity.Context)
+		}
+	case IdentityMerged:
+		// with Force, the cluster is added again as a new one below, and
+		// name conflicts are resolved by OnConflict as usual.
+		if m.opts.Force {
+			break
+		}
+
+		// already merged, only refresh or leave the existing entries.
+		switch m.opts.OnConflict {
+		case ConflictReplace:
+			return m.rotate(metas.Get(m.identity.Context), MergeReplaced)
+		case ConflictSkip:
+			m.skip(m.existingNames(m.identity.Context), m.opts.NameSuffix)
+			return nil
+		default:
+			return errors.Wrapf(ErrConfigAlreadyMerged, "context: [%v]", m.identity.Context)
+		}
+	case IdentitySharedCA:
+		// different cluster, added as a new one.
+		w := fmt.Sprintf("shares CA with %v, added as a different cluster", m.identity.Context)
+		m.summary.Warnings = append(m.summary.Warnings, w)
+		m.log.Warn("cluster shares CA with existing one", "context", m.identity.Context)
+	}
 
-	m.normalizeInName()
+	if errs := m.findConflicts(names); len(errs) > 0 {
+		for _, e := range errs {
+			m.summary.Conflicts = append(m.summary.Conflicts, e.Error())
+		}
+
+		switch m.opts.OnConflict {
+		case ConflictSkip:
+			m.skip(names, suffix)
+			return nil
+		case ConflictReplace:
+			if m.localPort == 0 {
+				m.localPort = m.existingLocalPort(names)
+			}
+			m.summary.Result = MergeReplaced
+		case ConflictRename:
+			if suffix, names, err = m.uniqueNames(); err != nil {
+				return err
+			}
+			m.summary.Result = MergeRenamed
+		default:
+			return errs[0]
+		}
+	}
 
-	if err := m.checkBeforeUpdate(); err != nil {
+	if err := m.checkLocalPort(names); err != nil {
 		return err
 	}
 
+	m.normalizeInName(suffix, names)
+
+	if m.summary.Result == MergeReplaced {
+		m.keepLocations(names)
+	}
+
 	m.mainKC.Clusters[m.inCK.Ctx.Cluster] = m.inCK.Cluster
 	if m.inCK.User != nil {
 		m.mainKC.AuthInfos[m.inCK.Ctx.AuthInfo] = m.inCK.User
 	}
 	m.mainKC.Contexts[m.inCK.CtxName] = m.inCK.Ctx
 
+	if m.summary.Result != MergeReplaced {
+		setLocation(m.mainKC, m.inCK.CtxName, m.store.Locate(suffix))
+	}
+
+	m.summary.NameSuffix = suffix
+	m.summary.Context = names.Context
+	m.summary.LocalPort = m.localPort
+
+	return nil
+}
+
+// skip leaves existing entries untouched.
+func (m *merger) skip(names Names, suffix string) {
+	m.localPort = m.existingLocalPort(names)
+	m.summary.Result = MergeSkipped
+	m.summary.NameSuffix = suffix
+	m.summary.Context = names.Context
+	m.summary.LocalPort = m.localPort
+}
+
+// existingNames returns names of entries used by given context.
+func (m *merger) existingNames(ctxName string) Names {
+	ctx := m.mainKC.Contexts[ctxName]
+
+	return Names{
+		Cluster: ctx.Cluster,
+		User:    ctx.AuthInfo,
+		Context: ctxName,
+	}
+}
+
+// rotate updates credentials of the existing cluster in place, keeping
+// its names, local port and metadata.
+func (m *merger) rotate(meta *Meta, result string) error {
+	ctxName := m.identity.Context
+	names := m.existingNames(ctxName)
+
+	suffix := m.inMeta.NameSuffix
+	if meta != nil {
+		inMeta := *meta
+		inMeta.RemoteUser = m.inMeta.RemoteUser
+		inMeta.RemoteHost = m.inMeta.RemoteHost
+		inMeta.RemotePort = m.inMeta.RemotePort
+		inMeta.OriginalContext = m.inMeta.OriginalContext
+		inMeta.Labels = mergeLabels(meta.Labels, m.opts.Labels)
+		m.inMeta = &inMeta
+		suffix = meta.NameSuffix
+	} else if legacy, err := legacyMeta(m.mainKC, ctxName); err == nil {
+		suffix = legacy.NameSuffix
+	}
+
+	if m.localPort == 0 {
+		m.localPort = m.existingLocalPort(names)
+	}
+
+	if err := m.checkLocalPort(names); err != nil {
+		return err
+	}
+
+	m.normalizeInName(suffix, names)
+	m.keepLocations(names)
+
+	m.mainKC.Clusters[names.Cluster] = m.inCK.Cluster
+	if m.inCK.User != nil {
+		m.mainKC.AuthInfos[names.User] = m.inCK.User
+	}
+	m.mainKC.Contexts[names.Context] = m.inCK.Ctx
+
+	m.summary.Result = result
+	m.summary.NameSuffix = suffix
+	m.summary.Context = names.Context
+	m.summary.LocalPort = m.localPort
+
 	return nil
 }
 
+// renderNames renders local names for remote cluster with given name suffix,
+// and prepares metadata accordingly.
+func (m *merger) renderNames(suffix string) (Names, error) {
+	remotePort, _ := base.GetPort(m.inCK.Cluster.Server)
+	m.inMeta = &Meta{
+		NameSuffix:      suffix,
+		RemoteUser:      base.ExtractUser(m.opts.RemoteAddr),
+		RemoteHost:      base.GetHostname(m.opts.RemoteAddr),
+		RemotePort:      remotePort,
+		OriginalContext: m.inCK.CtxName,
+		Labels:          m.opts.Labels,
+	}
+
+	return m.opts.Naming.Render(metaFields(m.inMeta))
+}
+
 // normalizeInName normalized local names for remote cluster.
-// The names for remote cluster should follow below conventions:
+// The names are rendered from naming templates, which by default follow
+// below conventions:
 // 1. cluster name: `kubernetes` + `-` + nameSuffix
-// 2. user name: `kubernetes-admin` + `-` + nameSuffix
+// 2. user name: `kubernetes` + `-` + nameSuffix
 // 3. context name: `kubernetes-admin` + `@` + `remoteIP:remotePort` + `-` + nameSuffix
-func (m *merger) normalizeInName() {
-	remoteHost := base.GetHost(m.opts.RemoteAddr)
-	remotePort, _ := base.GetPort(m.inCK.Cluster.Server)
+func (m *merger) normalizeInName(suffix string, names Names) {
+	m.inMeta.NameSuffix = suffix
+	m.inMeta.LocalPort = m.localPort
 
-	m.inCK.Ctx.AuthInfo = "kubernetes-" + m.opts.NameSuffix
-	m.inCK.Ctx.Cluster = "kubernetes-" + m.opts.NameSuffix
-	m.inCK.CtxName = fmt.Sprintf("%s@%s:%v-%s", "kubernetes-admin",
-		remoteHost, remotePort,
-		m.opts.NameSuffix)
+	m.inCK.Ctx.AuthInfo = names.User
+	m.inCK.Ctx.Cluster = names.Cluster
+	m.inCK.CtxName = names.Context
 
 	m.updatedClusterName = m.inCK.Ctx.Cluster
 
@@ -191,18 +480,19 @@ func (m *merger) normalizeInName() {
 		schema, DefaultHost, m.localPort)
 }
 
-func (m *merger) checkBeforeUpdate() error {
-	if _, ok := m.mainKC.Clusters[m.inCK.Ctx.Cluster]; ok {
-		return errors.Wrapf(ErrClusterAlreadyExists, "name: %v", m.inCK.Ctx.Cluster)
+// mergeLabels returns labels overridden by given ones.
+func mergeLabels(existing, override map[string]string) map[string]string {
+	if len(existing) == 0 && len(override) == 0 {
+		return nil
 	}
 
-	if _, ok := m.mainKC.AuthInfos[m.inCK.Ctx.AuthInfo]; ok {
-		return errors.Wrapf(ErrUserAlreadyExists, "name: %v", m.inCK.Ctx.AuthInfo)
+	ret := make(map[string]string, len(existing)+len(override))
+	for k, v := range existing {
+		ret[k] = v
 	}
-
-	if _, ok := m.mainKC.Contexts[m.inCK.CtxName]; ok {
-		return errors.Wrapf(ErrContextAlreadyExists, "name: %v", m.inCK.CtxName)
+	for k, v := range override {
+		ret[k] = v
 	}
 
-	return nil
+	return ret
 }
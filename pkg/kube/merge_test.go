@@ -0,0 +1,252 @@
+package kube
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+// newTestMerger creates a merger whose remote config is already downloaded.
+func newTestMerger(mainKC *clientcmdapi.Config, opts MergeOptions) *merger {
+	m := NewMerger(opts).(*merger)
+	m.mainKC = mainKC
+
+	m.inKC = newTestConfig("kubernetes", "https://172.31.7.182:6443")
+	m.inKC.Clusters["kubernetes"].CertificateAuthorityData = []byte("ca-" + opts.NameSuffix)
+	m.inClusterName = "kubernetes"
+	m.inCK = getClusterKeyInfo(m.inKC, m.inClusterName)
+
+	return m
+}
+
+func TestMerger_OnConflict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cube-merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	base.SetStateDir(dir)
+	defer base.SetStateDir("")
+
+	// existing context without metadata, which isn't identified by remote address.
+	existingCtx := "qa"
+	naming := Naming{DefaultClusterNameTmpl, DefaultUserNameTmpl, "{{.Suffix}}"}
+	newMainKC := func() *clientcmdapi.Config {
+		kc := newTestConfig("kubernetes-qa", "https://kubernetes:7005")
+		kc.Contexts[existingCtx] = kc.Contexts["kubernetes-qa"]
+		delete(kc.Contexts, "kubernetes-qa")
+		return kc
+	}
+
+	tests := []struct {
+		name string
+
+		// input
+		strategy  ConflictStrategy
+		localPort int
+
+		// output
+		hasErr       bool
+		result       string
+		suffix       string
+		expLocalPort int
+	}{
+		{"fail", ConflictFail, 7001, true, "", "", 0},
+		{"skip", ConflictSkip, 7001, false, MergeSkipped, "qa", 7005},
+		{"replace", ConflictReplace, 0, false, MergeReplaced, "qa", 7005},
+		{"rename", ConflictRename, 7001, false, MergeRenamed, "qa-2", 7001},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			m := newTestMerger(newMainKC(), MergeOptions{
+				RemoteAddr: "core@172.31.7.182",
+				NameSuffix: "qa",
+				OnConflict: test.strategy,
+				LocalPort:  test.localPort,
+				Naming:     naming,
+			})
+
+			err := m.doMerge()
+			if test.hasErr {
+				assert.True(errors.Is(err, ErrClusterAlreadyExists))
+				return
+			}
+
+			assert.Nil(err)
+
+			s := m.Summary()
+			assert.Equal(test.result, s.Result)
+			assert.Equal(test.suffix, s.NameSuffix)
+			assert.Equal(test.expLocalPort, s.LocalPort)
+			assert.Len(s.Conflicts, 3)
+
+			kc := m.Result()
+			switch test.strategy {
+			case ConflictReplace:
+				assert.Len(kc.Contexts, 1)
+				assert.Equal("https://kubernetes:7005", kc.Clusters["kubernetes-qa"].Server)
+				assert.Equal("token-kubernetes", kc.AuthInfos["kubernetes-qa"].Token)
+			case ConflictRename:
+				assert.Len(kc.Contexts, 2)
+				assert.Contains(kc.Contexts, "qa-2")
+			case ConflictSkip:
+				assert.Len(kc.Contexts, 1)
+				assert.Equal("token-kubernetes-qa", kc.AuthInfos["kubernetes-qa"].Token)
+			}
+		})
+	}
+}
+
+func TestMerger_identityOutcome(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cube-merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fetch := fetchRemoteSANs
+	fetchRemoteSANs = func(addr, via string, stderr io.Writer) ([]string, error) { return nil, errNoCertFound }
+	defer func() { fetchRemoteSANs = fetch }()
+
+	existingCtx := "kubernetes-dev"
+	newMainKC := func(ca string) *clientcmdapi.Config {
+		kc := newTestConfig(existingCtx, "https://kubernetes:7005")
+		kc.Clusters[existingCtx].CertificateAuthorityData = []byte(ca)
+		return kc
+	}
+
+	tests := []struct {
+		name string
+
+		// input
+		remoteHost string
+		ca         string
+		strategy   ConflictStrategy
+		force      bool
+
+		// output
+		err      error
+		result   string
+		contexts int
+		warnings int
+	}{
+		{"rotated-fail", "172.31.7.182", "ca-old", ConflictFail, false, ErrCredentialsRotated, "", 0, 0},
+		{"rotated-skip", "172.31.7.182", "ca-old", ConflictSkip, false, nil, MergeSkipped, 1, 0},
+		{"rotated-replace", "172.31.7.182", "ca-old", ConflictReplace, false, nil, MergeRotated, 1, 0},
+		{"rotated-force", "172.31.7.182", "ca-old", ConflictFail, true, nil, MergeRotated, 1, 0},
+		{"shared-ca", "172.31.7.183", "ca-qa", ConflictFail, false, nil, MergeAdded, 2, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			metas := Metas{existingCtx: &Meta{NameSuffix: "dev", RemoteHost: test.remoteHost, RemotePort: 6443, LocalPort: 7005}}
+			assert.Nil(metas.SaveTo(base.NewState(dir).MetaPath()))
+
+			m := newTestMerger(newMainKC(test.ca), MergeOptions{
+				RemoteAddr: "core@172.31.7.182",
+				NameSuffix: "qa",
+				LocalPort:  7001,
+				OnConflict: test.strategy,
+				Force:      test.force,
+				StateDir:   dir,
+			})
+
+			err := m.doMerge()
+			if test.err != nil {
+				assert.True(errors.Is(err, test.err))
+				return
+			}
+			assert.Nil(err)
+
+			s := m.Summary()
+			assert.Equal(test.result, s.Result)
+			assert.Len(s.Warnings, test.warnings)
+
+			kc := m.Result()
+			assert.Len(kc.Contexts, test.contexts)
+
+			ca := string(kc.Clusters[existingCtx].CertificateAuthorityData)
+			if test.result == MergeRotated {
+				assert.Equal("ca-qa", ca)
+			} else {
+				assert.Equal(test.ca, ca)
+			}
+		})
+	}
+}
+
+func TestMerger_identify(t *testing.T) {
+	sans := map[string][]string{}
+	fetch := fetchRemoteSANs
+	fetchRemoteSANs = func(addr, via string, stderr io.Writer) ([]string, error) {
+		if v, ok := sans[addr]; ok && via == "user@jump" {
+			return v, nil
+		}
+		return nil, errNoCertFound
+	}
+	defer func() { fetchRemoteSANs = fetch }()
+
+	existingCtx := "kubernetes-admin@172.31.7.182:6443-qa"
+	mainKC := newTestConfig("kubernetes-qa", "https://kubernetes:7005")
+	mainKC.Contexts[existingCtx] = mainKC.Contexts["kubernetes-qa"]
+	delete(mainKC.Contexts, "kubernetes-qa")
+	mainKC.Clusters["kubernetes-qa"].CertificateAuthorityData = []byte("ca")
+	mainKC.AuthInfos["kubernetes-qa"].Token = "token"
+
+	metas := Metas{existingCtx: &Meta{NameSuffix: "qa", RemoteHost: "172.31.7.182", RemotePort: 6443, LocalPort: 7005}}
+
+	tests := []struct {
+		name string
+
+		// input
+		remoteHost string
+		ca         string
+		token      string
+		sans       map[string][]string
+
+		// output
+		identity Identity
+	}{
+		{"new", "172.31.7.183", "ca-new", "token", nil, IdentityNew},
+		{"merged", "172.31.7.182", "ca", "token", nil, IdentityMerged},
+		{"rotated-ca", "172.31.7.182", "ca-new", "token", nil, IdentityRotated},
+		{"rotated-token", "172.31.7.182", "ca", "token-new", nil, IdentityRotated},
+		{"shared-ca", "172.31.7.183", "ca", "token", nil, IdentitySharedCA},
+		{"shared-ca-same-server", "172.31.7.183", "ca", "token",
+			map[string][]string{
+				"172.31.7.182:6443": {"kubernetes", "10.0.0.1"},
+				"172.31.7.183:6443": {"10.0.0.1", "kubernetes"},
+			}, IdentityMerged},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			sans = test.sans
+
+			in := newTestConfig("kubernetes", "https://"+test.remoteHost+":6443")
+			in.Clusters["kubernetes"].CertificateAuthorityData = []byte(test.ca)
+			in.AuthInfos["kubernetes"].Token = test.token
+			inMeta := &Meta{RemoteHost: test.remoteHost, RemotePort: 6443}
+
+			match := identify(mainKC, metas, getClusterKeyInfo(in, "kubernetes"), inMeta, "user@jump", ioutil.Discard)
+			assert.Equal(test.identity, match.Identity)
+			if test.identity != IdentityNew {
+				assert.Equal(existingCtx, match.Context)
+			}
+		})
+	}
+}
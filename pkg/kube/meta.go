@@ -0,0 +1,119 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+// Meta is cube metadata for a managed cluster. It's kept outside
+// kubeconfig, so that cluster info doesn't depend on local names.
+type Meta struct {
+	NameSuffix      string `json:"nameSuffix"`
+	RemoteUser      string `json:"remoteUser,omitempty"`
+	RemoteHost      string `json:"remoteHost"`
+	RemotePort      int    `json:"remotePort"`
+	LocalPort       int    `json:"localPort"`
+	OriginalContext string `json:"originalContext,omitempty"`
+
+	// Labels are user defined key/value pairs to select clusters, e.g. env=prod.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// RemoteAPIAddr returns remote API address, e.g. 172.10.0.1:6443
+func (m Meta) RemoteAPIAddr() string {
+	return fmt.Sprintf("%v:%v", m.RemoteHost, m.RemotePort)
+}
+
+// Metas holds metadata of all managed clusters, keyed by context name.
+type Metas map[string]*Meta
+
+// LoadMetas reads cube metadata from state dir. Missing file means no metadata.
+func LoadMetas() (Metas, error) {
+	return LoadMetasFrom(base.DefaultState().MetaPath())
+}
+
+// LoadMetasFrom reads cube metadata from given file.
+func LoadMetasFrom(p string) (Metas, error) {
+	content, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return make(Metas), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ms := make(Metas)
+	if len(content) == 0 {
+		return ms, nil
+	}
+
+	if err := json.Unmarshal(content, &ms); err != nil {
+		return nil, err
+	}
+
+	// drop `null` entries, which carry no metadata.
+	for k, m := range ms {
+		if m == nil {
+			delete(ms, k)
+		}
+	}
+
+	return ms, nil
+}
+
+// Save writes cube metadata to state dir.
+func (ms Metas) Save() error {
+	return ms.SaveTo(base.DefaultState().MetaPath())
+}
+
+// SaveTo writes cube metadata to given file.
+func (ms Metas) SaveTo(p string) error {
+	content, err := json.MarshalIndent(ms, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p, content, 0644)
+}
+
+// Get returns metadata for given context, nil if not found.
+func (ms Metas) Get(ctxName string) *Meta {
+	if ms == nil {
+		return nil
+	}
+
+	return ms[ctxName]
+}
+
+// Rename moves metadata from old context name to the new one.
+func (ms Metas) Rename(oldCtx, newCtx string) {
+	m, ok := ms[oldCtx]
+	if !ok || oldCtx == newCtx {
+		return
+	}
+
+	delete(ms, oldCtx)
+	ms[newCtx] = m
+}
+
+// Names returns all context names with metadata, sorted.
+func (ms Metas) Names() []string {
+	ret := make([]string, 0, len(ms))
+	for k := range ms {
+		ret = append(ret, k)
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
@@ -0,0 +1,162 @@
+package kube
+
+import (
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+var (
+	errNotManagedContext = errors.New("cube: context not managed by cube")
+)
+
+// Migrator renames managed clusters following naming templates
+type Migrator interface {
+	Migrate() error
+	Save() error
+	Result() *clientcmdapi.Config
+	Renamed() map[string]string
+}
+
+// MigrateOptions represents options for migrate.
+type MigrateOptions struct {
+	// Naming is templates for local names. If not set, DefaultNaming is used.
+	Naming Naming
+
+	// Store is where kubeconfig is loaded and saved. If not set, NewStore is used.
+	Store *Store
+
+	// StateDir is where metadata is kept. If not set, base.StateDir is used.
+	StateDir string
+}
+
+type migrator struct {
+	opts   MigrateOptions
+	store  *Store
+	state  base.State
+	mainKC *clientcmdapi.Config
+	metas  Metas
+
+	renamed map[string]string
+}
+
+func NewMigrator(opts MigrateOptions) Migrator {
+	if opts.Naming == (Naming{}) {
+		opts.Naming = DefaultNaming()
+	}
+
+	if opts.Store == nil {
+		opts.Store = NewStore()
+	}
+
+	return &migrator{
+		opts:    opts,
+		store:   opts.Store,
+		state:   base.NewState(opts.StateDir),
+		renamed: make(map[string]string),
+	}
+}
+
+// Migrate renames cluster, user and context of every managed cluster
+// to the names rendered from naming templates. Metadata is recorded for
+// clusters merged before metadata is introduced.
+func (m *migrator) Migrate() error {
+	var err error
+	if m.mainKC, err = m.store.Load(); err != nil {
+		return err
+	}
+
+	if m.metas, err = LoadMetasFrom(m.state.MetaPath()); err != nil {
+		return err
+	}
+
+	ctxNames := make([]string, 0, len(m.mainKC.Contexts))
+	for k := range m.mainKC.Contexts {
+		ctxNames = append(ctxNames, k)
+	}
+	sort.Strings(ctxNames)
+
+	for _, k := range ctxNames {
+		meta := m.metas.Get(k)
+		if meta == nil {
+			meta, err = legacyMeta(m.mainKC, k)
+			if err != nil {
+				continue
+			}
+			m.metas[k] = meta
+		}
+
+		names, err := m.opts.Naming.Render(metaFields(meta))
+		if err != nil {
+			return err
+		}
+
+		if err := renameEntries(m.mainKC, k, names); err != nil {
+			return err
+		}
+
+		if k != names.Context {
+			m.metas.Rename(k, names.Context)
+			m.renamed[k] = names.Context
+		}
+	}
+
+	return nil
+}
+
+// Save writes renamed kubeconfig to local store, and updates metadata.
+func (m *migrator) Save() error {
+	if err := m.store.Save(m.mainKC); err != nil {
+		return err
+	}
+
+	return m.metas.SaveTo(m.state.MetaPath())
+}
+
+func (m *migrator) Result() *clientcmdapi.Config {
+	return m.mainKC
+}
+
+// Renamed returns renamed contexts, old name => new name.
+func (m *migrator) Renamed() map[string]string {
+	return m.renamed
+}
+
+// legacyMeta builds metadata by parsing context name in legacy format,
+// i.e. `kubernetes-admin@172.31.7.182:6443-test`.
+func legacyMeta(kc *clientcmdapi.Config, ctxName string) (*Meta, error) {
+	ctx := kc.Contexts[ctxName]
+	cluster, ok := kc.Clusters[ctx.Cluster]
+	if !ok {
+		return nil, errors.Wrapf(errClusterNotFound, "ctx: %v", ctxName)
+	}
+
+	h, localPort, err := GetOccupiedLocalPort(cluster.Server)
+	if err != nil || h != DefaultHost {
+		return nil, errors.Wrapf(errNotManagedContext, "ctx: %v", ctxName)
+	}
+
+	remote := getRemoteHostFromCtx(ctxName)
+	suffix := getNameSuffix(ctxName)
+	if remote == "" || suffix == "" {
+		return nil, errors.Wrapf(errNoAddrInContextName, "ctx: %v", ctxName)
+	}
+
+	host, portStr, err := net.SplitHostPort(remote)
+	if err != nil {
+		return nil, errors.Wrapf(errNoAddrInContextName, "ctx: %v", ctxName)
+	}
+	remotePort, _ := strconv.Atoi(portStr)
+
+	return &Meta{
+		NameSuffix: suffix,
+		RemoteHost: host,
+		RemotePort: remotePort,
+		LocalPort:  localPort,
+	}, nil
+}
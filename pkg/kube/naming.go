@@ -0,0 +1,182 @@
+package kube
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	DefaultClusterNameTmpl = "kubernetes-{{.Suffix}}"
+	DefaultUserNameTmpl    = "kubernetes-{{.Suffix}}"
+	DefaultContextNameTmpl = "kubernetes-admin@{{.Host}}:{{.Port}}-{{.Suffix}}"
+
+	// env vars to override default naming templates
+	envClusterNameTmpl = "CUBE_CLUSTER_NAME_TEMPLATE"
+	envUserNameTmpl    = "CUBE_USER_NAME_TEMPLATE"
+	envContextNameTmpl = "CUBE_CONTEXT_NAME_TEMPLATE"
+)
+
+var (
+	ErrInvalidNameTemplate = errors.New("cube: invalid name template")
+	ErrEmptyName           = errors.New("cube: empty name rendered from template")
+)
+
+// NameFields are the fields available in naming templates.
+type NameFields struct {
+	Suffix     string // name suffix
+	Host       string // remote master host
+	Port       int    // remote API port
+	RemoteUser string // remote ssh user
+	Context    string // context name in remote kubeconfig
+}
+
+// Naming holds Go templates for local names of a managed cluster.
+type Naming struct {
+	Cluster string
+	User    string
+	Context string
+}
+
+// Names are local names of a managed cluster.
+type Names struct {
+	Cluster string
+	User    string
+	Context string
+}
+
+// DefaultNaming returns naming templates, which can be overridden by
+// `CUBE_CLUSTER_NAME_TEMPLATE`, `CUBE_USER_NAME_TEMPLATE` and
+// `CUBE_CONTEXT_NAME_TEMPLATE` env.
+func DefaultNaming() Naming {
+	return Naming{
+		Cluster: envOr(envClusterNameTmpl, DefaultClusterNameTmpl),
+		User:    envOr(envUserNameTmpl, DefaultUserNameTmpl),
+		Context: envOr(envContextNameTmpl, DefaultContextNameTmpl),
+	}
+}
+
+func envOr(key, defaultVal string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return defaultVal
+}
+
+// Render renders local names with given fields.
+func (n Naming) Render(f NameFields) (Names, error) {
+	var names Names
+	var err error
+
+	if names.Cluster, err = render(n.Cluster, f); err != nil {
+		return names, err
+	}
+	if names.User, err = render(n.User, f); err != nil {
+		return names, err
+	}
+	if names.Context, err = render(n.Context, f); err != nil {
+		return names, err
+	}
+
+	return names, nil
+}
+
+func render(tmpl string, f NameFields) (string, error) {
+	t, err := template.New("name").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", errors.Wrapf(ErrInvalidNameTemplate, "template: %v, err: %v", tmpl, err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, f); err != nil {
+		return "", errors.Wrapf(ErrInvalidNameTemplate, "template: %v, err: %v", tmpl, err)
+	}
+
+	name := strings.TrimSpace(buf.String())
+	if name == "" {
+		return "", errors.Wrapf(ErrEmptyName, "template: %v", tmpl)
+	}
+
+	return name, nil
+}
+
+// metaFields converts metadata to naming fields.
+func metaFields(m *Meta) NameFields {
+	return NameFields{
+		Suffix:     m.NameSuffix,
+		Host:       m.RemoteHost,
+		Port:       m.RemotePort,
+		RemoteUser: m.RemoteUser,
+		Context:    m.OriginalContext,
+	}
+}
+
+// checkRename checks whether the entries of given context can be renamed
+// to new names without overriding other entries.
+func checkRename(kc *clientcmdapi.Config, ctxName string, names Names) error {
+	ctx, ok := kc.Contexts[ctxName]
+	if !ok {
+		return errors.Wrapf(errClusterNotFound, "ctx: %v", ctxName)
+	}
+
+	if _, ok := kc.Clusters[names.Cluster]; ok && names.Cluster != ctx.Cluster {
+		return errors.Wrapf(ErrClusterAlreadyExists, "name: %v", names.Cluster)
+	}
+
+	if _, ok := kc.AuthInfos[names.User]; ok && names.User != ctx.AuthInfo {
+		return errors.Wrapf(ErrUserAlreadyExists, "name: %v", names.User)
+	}
+
+	if _, ok := kc.Contexts[names.Context]; ok && names.Context != ctxName {
+		return errors.Wrapf(ErrContextAlreadyExists, "name: %v", names.Context)
+	}
+
+	return nil
+}
+
+// renameEntries renames cluster, user and context of given context
+// consistently. Other contexts referring to the same cluster or user, as
+// well as `current-context`, are updated accordingly.
+func renameEntries(kc *clientcmdapi.Config, ctxName string, names Names) error {
+	if err := checkRename(kc, ctxName, names); err != nil {
+		return err
+	}
+
+	ctx := kc.Contexts[ctxName]
+	oldCluster, oldUser := ctx.Cluster, ctx.AuthInfo
+
+	if c, ok := kc.Clusters[oldCluster]; ok && oldCluster != names.Cluster {
+		delete(kc.Clusters, oldCluster)
+		kc.Clusters[names.Cluster] = c
+	}
+
+	if u, ok := kc.AuthInfos[oldUser]; ok && oldUser != names.User {
+		delete(kc.AuthInfos, oldUser)
+		kc.AuthInfos[names.User] = u
+	}
+
+	for _, v := range kc.Contexts {
+		if v.Cluster == oldCluster {
+			v.Cluster = names.Cluster
+		}
+		if v.AuthInfo == oldUser {
+			v.AuthInfo = names.User
+		}
+	}
+
+	if ctxName != names.Context {
+		delete(kc.Contexts, ctxName)
+		kc.Contexts[names.Context] = ctx
+
+		if kc.CurrentContext == ctxName {
+			kc.CurrentContext = names.Context
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNaming_Render(t *testing.T) {
+	fields := NameFields{
+		Suffix:     "qa",
+		Host:       "172.31.7.182",
+		Port:       6443,
+		RemoteUser: "core",
+		Context:    "kubernetes-admin@kubernetes",
+	}
+
+	tests := []struct {
+		name string
+
+		// input
+		naming Naming
+
+		// output
+		names  Names
+		hasErr bool
+	}{
+		{"default",
+			Naming{DefaultClusterNameTmpl, DefaultUserNameTmpl, DefaultContextNameTmpl},
+			Names{"kubernetes-qa", "kubernetes-qa", "kubernetes-admin@172.31.7.182:6443-qa"}, false},
+		{"short",
+			Naming{"{{.Suffix}}", "{{.RemoteUser}}-{{.Suffix}}", "{{.Suffix}}"},
+			Names{"qa", "core-qa", "qa"}, false},
+		{"invalid-field",
+			Naming{"{{.Region}}", "{{.Suffix}}", "{{.Suffix}}"},
+			Names{}, true},
+		{"empty",
+			Naming{"{{.Suffix}}", "{{.Suffix}}", "{{if false}}x{{end}}"},
+			Names{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			names, err := test.naming.Render(fields)
+			if test.hasErr {
+				assert.NotNil(err)
+				return
+			}
+
+			assert.Nil(err)
+			assert.Equal(test.names, names)
+		})
+	}
+}
+
+func TestNaming_renameEntries(t *testing.T) {
+	assert := assert.New(t)
+
+	kc := newTestConfig("a", "https://kubernetes:7001")
+	other := newTestConfig("b", "https://kubernetes:7002")
+	kc.Clusters["b"] = other.Clusters["b"]
+	kc.AuthInfos["b"] = other.AuthInfos["b"]
+	kc.Contexts["b"] = other.Contexts["b"]
+	kc.CurrentContext = "a"
+
+	err := renameEntries(kc, "a", Names{Cluster: "b", User: "a2", Context: "a2"})
+	assert.NotNil(err)
+
+	err = renameEntries(kc, "a", Names{Cluster: "c2", User: "u2", Context: "a2"})
+	assert.Nil(err)
+
+	assert.Contains(kc.Clusters, "c2")
+	assert.Contains(kc.AuthInfos, "u2")
+	assert.Contains(kc.Contexts, "a2")
+	assert.NotContains(kc.Contexts, "a")
+	assert.Equal("c2", kc.Contexts["a2"].Cluster)
+	assert.Equal("u2", kc.Contexts["a2"].AuthInfo)
+	assert.Equal("a2", kc.CurrentContext)
+}
+
+func TestMigrate_legacyMeta(t *testing.T) {
+	assert := assert.New(t)
+
+	ctxName := "kubernetes-admin@172.31.7.182:6443-qa-eu"
+	kc := newTestConfig("kubernetes-qa-eu", "https://kubernetes:7003")
+	kc.Contexts[ctxName] = kc.Contexts["kubernetes-qa-eu"]
+
+	meta, err := legacyMeta(kc, ctxName)
+	assert.Nil(err)
+	assert.Equal(&Meta{
+		NameSuffix: "qa-eu",
+		RemoteHost: "172.31.7.182",
+		RemotePort: 6443,
+		LocalPort:  7003,
+	}, meta)
+
+	_, err = legacyMeta(kc, "kubernetes-qa-eu")
+	assert.NotNil(err)
+}
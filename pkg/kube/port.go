@@ -1,12 +1,20 @@
 package kube
 
 import (
-	"log"
+	"fmt"
 	"net/url"
+	"os"
+	"sort"
 	"strconv"
+	"strings"
 
-	"github.com/shohi/cube/pkg/base"
+	"github.com/pkg/errors"
+	psnet "github.com/shirou/gopsutil/net"
+	"github.com/shirou/gopsutil/process"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/log"
 )
 
 const (
@@ -14,34 +22,105 @@ const (
 	maxLocalPort = 7100
 
 	defaultHost = "kubernetes"
+
+	// env var to override default local port range, e.g. `7001-7100`
+	envPortRange = "CUBE_PORT_RANGE"
 )
 
-// getNextLocalPort get next available local port.
-// It checks the cluster whose server is in format `https://kubernetes:xxx`.
-func getNextLocalPort(kc *clientcmdapi.Config) int {
-	ocPorts := getAllOccupiedLocalPort(kc)
-	var max = minLocalPort - 1
+// Port kinds
+const (
+	PortCube     = "cube"     // used by a managed cluster
+	PortReserved = "reserved" // recorded in metadata, but cluster not in kubeconfig
+	PortListener = "listener" // taken by a non-cube listener
+)
 
-	for _, v := range ocPorts {
-		if v < minLocalPort || v > maxLocalPort {
-			continue
+var (
+	ErrInvalidPortRange = errors.New("cube: invalid local port range")
+	ErrNoAvailablePort  = errors.New("cube: no available local port in range")
+	ErrLocalPortInUse   = errors.New("cube: local port already in use")
+)
+
+// portAvailable checks whether the port is free on localhost, replaceable in tests.
+var portAvailable = base.IsAvailable
+
+// PortRange is the range of local forwarding ports, both ends inclusive.
+type PortRange struct {
+	Min int
+	Max int
+}
+
+func (r PortRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// Contains checks whether the port is in range.
+func (r PortRange) Contains(port int) bool {
+	return port >= r.Min && port <= r.Max
+}
+
+// ParsePortRange parses port range in the format of `min-max`.
+func ParsePortRange(s string) (PortRange, error) {
+	tokens := strings.Split(strings.TrimSpace(s), SepHyphen)
+	if len(tokens) != 2 {
+		return PortRange{}, errors.Wrapf(ErrInvalidPortRange, "range: %v", s)
+	}
+
+	min, err1 := strconv.Atoi(strings.TrimSpace(tokens[0]))
+	max, err2 := strconv.Atoi(strings.TrimSpace(tokens[1]))
+	if err1 != nil || err2 != nil || min <= 0 || max > 65535 || min > max {
+		return PortRange{}, errors.Wrapf(ErrInvalidPortRange, "range: %v", s)
+	}
+
+	return PortRange{Min: min, Max: max}, nil
+}
+
+// DefaultPortRange returns local port range, which can be overridden by
+// `CUBE_PORT_RANGE` env.
+func DefaultPortRange() PortRange {
+	return defaultPortRange(log.Default())
+}
+
+func defaultPortRange(lg *log.Logger) PortRange {
+	if v := os.Getenv(envPortRange); v != "" {
+		if r, err := ParsePortRange(v); err == nil {
+			return r
 		}
-		if v > max {
-			max = v
+		lg.Warn("invalid port range, use default", "env", envPortRange, "value", v)
+	}
+
+	return PortRange{Min: minLocalPort, Max: maxLocalPort}
+}
+
+// getNextLocalPort gets the lowest available local port in range, so that
+// ports freed by deleted clusters are reused. A port is available if it's
+// not used by any cluster whose server is in format `https://kubernetes:xxx`,
+// not reserved in metadata, and not taken by other listeners.
+func getNextLocalPort(kc *clientcmdapi.Config, metas Metas, r PortRange, lg *log.Logger) (int, error) {
+	used := make(map[int]bool)
+	for _, p := range getAllOccupiedLocalPort(kc, lg) {
+		used[p] = true
+	}
+	for _, m := range metas {
+		if m != nil {
+			used[m.LocalPort] = true
 		}
 	}
 
-	for k := max + 1; k < maxLocalPort; k++ {
-		if base.IsAvailable(k) {
-			return k
+	for k := r.Min; k <= r.Max; k++ {
+		if used[k] {
+			continue
 		}
 
+		if portAvailable(k) {
+			return k, nil
+		}
 	}
 
-	return -1
+	return -1, errors.Wrapf(ErrNoAvailablePort, "range: %v", r)
 }
 
-func getAllOccupiedLocalPort(kc *clientcmdapi.Config) []int {
+// getAllOccupiedLocalPort returns local ports used by managed clusters.
+func getAllOccupiedLocalPort(kc *clientcmdapi.Config, lg *log.Logger) []int {
 	if kc == nil || len(kc.Clusters) == 0 {
 		return nil
 	}
@@ -53,9 +132,13 @@ func getAllOccupiedLocalPort(kc *clientcmdapi.Config) []int {
 			continue
 		}
 
-		_, p, err := GetOccupiedLocalPort(v.Server)
+		h, p, err := GetOccupiedLocalPort(v.Server)
 		if err != nil {
-			log.Printf("failed to get port from server address - [%v], err: %v\n", v.Server, err)
+			lg.Debug("failed to get port from server address", "server", v.Server, "err", err)
+			continue
+		}
+
+		if h != defaultHost {
 			continue
 		}
 		ret = append(ret, p)
@@ -89,3 +172,119 @@ func getPort(u *url.URL) (int, error) {
 
 	return int(p), nil
 }
+
+// PortInfo shows who owns a local port.
+type PortInfo struct {
+	Port     int      `json:"port"`
+	Kind     string   `json:"kind"`
+	Owners   []string `json:"owners,omitempty"`
+	Listener string   `json:"listener,omitempty"`
+}
+
+type PortInfos []PortInfo
+
+func (p PortInfos) Len() int           { return len(p) }
+func (p PortInfos) Less(i, j int) bool { return p[i].Port < p[j].Port }
+func (p PortInfos) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// ListPorts shows owners of local ports used by managed clusters, reserved
+// in metadata, or taken by other listeners within given range.
+func ListPorts(r PortRange) (PortInfos, error) {
+	kc, err := LoadLocal()
+	if err != nil {
+		return nil, err
+	}
+
+	metas, err := LoadMetas()
+	if err != nil {
+		return nil, err
+	}
+
+	listeners, err := localListeners()
+	if err != nil {
+		log.Warn("failed to get local listeners", "err", err)
+	}
+
+	return genPortInfos(kc, metas, listeners, r), nil
+}
+
+func genPortInfos(kc *clientcmdapi.Config, metas Metas, listeners map[int]string, r PortRange) PortInfos {
+	infos := make(map[int]*PortInfo)
+	get := func(port int, kind string) *PortInfo {
+		if _, ok := infos[port]; !ok {
+			infos[port] = &PortInfo{Port: port, Kind: kind}
+		}
+		return infos[port]
+	}
+
+	for k, ctx := range kc.Contexts {
+		c, ok := kc.Clusters[ctx.Cluster]
+		if !ok {
+			continue
+		}
+
+		h, p, err := GetOccupiedLocalPort(c.Server)
+		if err != nil || h != defaultHost {
+			continue
+		}
+
+		info := get(p, PortCube)
+		info.Owners = append(info.Owners, k)
+	}
+
+	for k, m := range metas {
+		if _, ok := kc.Contexts[k]; ok || m == nil || m.LocalPort <= 0 {
+			continue
+		}
+
+		info := get(m.LocalPort, PortReserved)
+		info.Owners = append(info.Owners, k)
+	}
+
+	for p, name := range listeners {
+		info, ok := infos[p]
+		if !ok {
+			if !r.Contains(p) {
+				continue
+			}
+			info = get(p, PortListener)
+		}
+		info.Listener = name
+	}
+
+	ret := make(PortInfos, 0, len(infos))
+	for _, v := range infos {
+		sort.Strings(v.Owners)
+		ret = append(ret, *v)
+	}
+
+	sort.Sort(ret)
+
+	return ret
+}
+
+// localListeners returns local tcp listeners, port => process name.
+func localListeners() (map[int]string, error) {
+	conns, err := psnet.Connections("tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[int]string)
+	for _, c := range conns {
+		if c.Status != "LISTEN" {
+			continue
+		}
+
+		name := fmt.Sprintf("pid:%d", c.Pid)
+		if p, err := process.NewProcess(c.Pid); err == nil {
+			if n, err := p.Name(); err == nil {
+				name = fmt.Sprintf("%s(%d)", n, c.Pid)
+			}
+		}
+
+		ret[int(c.Laddr.Port)] = name
+	}
+
+	return ret, nil
+}
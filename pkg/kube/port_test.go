@@ -1,11 +1,90 @@
 package kube
 
 import (
-	"log"
 	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shohi/cube/pkg/log"
 )
 
 func TestPort_getOccupiedLocalPort(t *testing.T) {
 	srv := "https://kubernetes:8001"
-	log.Println(getOccupiedLocalPort(srv))
+	t.Log(GetOccupiedLocalPort(srv))
+}
+
+func TestPort_ParsePortRange(t *testing.T) {
+	tests := []struct {
+		name string
+
+		// input
+		s string
+
+		// output
+		r      PortRange
+		hasErr bool
+	}{
+		{"normal", "7001-7100", PortRange{7001, 7100}, false},
+		{"spaces", " 8000 - 8010 ", PortRange{8000, 8010}, false},
+		{"single", "7001-7001", PortRange{7001, 7001}, false},
+		{"reversed", "7100-7001", PortRange{}, true},
+		{"no-max", "7001", PortRange{}, true},
+		{"too-large", "7001-70000", PortRange{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, err := ParsePortRange(test.s)
+			assert.Equal(test.hasErr, err != nil)
+			assert.Equal(test.r, r)
+		})
+	}
+}
+
+func TestPort_getNextLocalPort(t *testing.T) {
+	assert := assert.New(t)
+
+	busy := map[int]bool{7003: true}
+	available := portAvailable
+	portAvailable = func(p int) bool { return !busy[p] }
+	defer func() { portAvailable = available }()
+
+	kc := newTestConfig("a", "https://kubernetes:7001")
+	other := newTestConfig("b", "https://kubernetes:7005")
+	kc.Clusters["b"] = other.Clusters["b"]
+	direct := newTestConfig("c", "https://10.0.0.1:7002")
+	kc.Clusters["c"] = direct.Clusters["c"]
+
+	// nil entry, e.g. `null` in meta.json, is skipped.
+	metas := Metas{"reserved": &Meta{LocalPort: 7002}, "broken": nil}
+
+	// 7001 used, 7002 reserved, 7003 taken by other listener, gap 7004 reused.
+	p, err := getNextLocalPort(kc, metas, PortRange{7001, 7010}, log.Default())
+	assert.Nil(err)
+	assert.Equal(7004, p)
+
+	_, err = getNextLocalPort(kc, metas, PortRange{7001, 7003}, log.Default())
+	assert.True(errors.Is(err, ErrNoAvailablePort))
+}
+
+func TestPort_genPortInfos(t *testing.T) {
+	assert := assert.New(t)
+
+	kc := newTestConfig("a", "https://kubernetes:7001")
+	metas := Metas{"gone": &Meta{LocalPort: 7002}, "broken": nil}
+	listeners := map[int]string{
+		7001: "ssh(100)",
+		7003: "nginx(200)",
+		9000: "java(300)",
+	}
+
+	infos := genPortInfos(kc, metas, listeners, PortRange{7001, 7100})
+	assert.Equal(PortInfos{
+		{Port: 7001, Kind: PortCube, Owners: []string{"a"}, Listener: "ssh(100)"},
+		{Port: 7002, Kind: PortReserved, Owners: []string{"gone"}},
+		{Port: 7003, Kind: PortListener, Listener: "nginx(200)"},
+	}, infos)
 }
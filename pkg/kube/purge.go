@@ -1,52 +1,111 @@
 package kube
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
 	"github.com/pkg/errors"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/matcher"
 )
 
 var (
 	ErrClusterNotFound       = errors.New("cube: cluster not found for purging")
 	ErrMultipleClustersFound = errors.New("cube: multiple clusters found for purging")
+	ErrEmptyPurgeTarget      = errors.New("cube: name or selector required for purging")
+	ErrPurgeCurrentContext   = errors.New("cube: refuse to purge current-context, use --allow-current to force")
 )
 
 // Purger deletes Kubernetes configs under given conditions
 type Purger interface {
 	Purge() error
+	Save() error
 	Result() *clientcmdapi.Config
 	Deleted() []string
+	Plan() PurgePlan
 }
 
 // PurgeOptions represent options for purge.
 type PurgeOptions struct {
-	Name string
-	All  bool
+	Name     string
+	Selector string // label selector, e.g. env=prod
+	All      bool
+
+	// Match is how name is matched. If not set, matcher.Exact is used.
+	Match matcher.Mode
+
+	// AllowCurrent allows to purge current-context.
+	AllowCurrent bool
+
+	// Store is where kubeconfig is loaded and saved. If not set, NewStore is used.
+	Store *Store
+
+	// StateDir is where metadata, certs and caches are kept. If not set,
+	// base.StateDir is used.
+	StateDir string
 }
 
 type purger struct {
 	opts   PurgeOptions
+	store  *Store
+	state  base.State
 	mainKC *clientcmdapi.Config
 
+	metas        Metas
 	selectedCtxs map[string]*clientcmdapi.Context
+	plan         PurgePlan
 }
 
 func NewPurger(opts PurgeOptions) Purger {
+	if opts.Match == "" {
+		opts.Match = matcher.Exact
+	}
+
+	if opts.Store == nil {
+		opts.Store = NewStore()
+	}
+
 	return &purger{
-		opts: opts,
+		opts:  opts,
+		store: opts.Store,
+		state: base.NewState(opts.StateDir),
 	}
 }
 
-// Purge delete Kubernetes config whose context name matches the given pattern.
+// Purge delete Kubernetes config whose name matches the given pattern and
+// labels match the selector.
 func (p *purger) Purge() error {
-	mainKC, err := Load(base.GetLocalKubePath())
+	if p.opts.Name == "" && p.opts.Selector == "" {
+		return ErrEmptyPurgeTarget
+	}
+
+	sel, err := ParseSelector(p.opts.Selector)
+	if err != nil {
+		return err
+	}
+
+	m, err := matcher.New(p.opts.Match, p.opts.Name)
+	if err != nil {
+		return err
+	}
+
+	mainKC, err := p.store.Load()
 	if err != nil {
 		return err
 	}
 	p.mainKC = mainKC
 
-	p.selectedCtxs = FindContextsByName(p.mainKC, p.opts.Name, nil)
+	metas, err := LoadMetasFrom(p.state.MetaPath())
+	if err != nil {
+		return err
+	}
+
+	p.selectedCtxs = SelectContexts(FindContexts(p.mainKC, metas, m, nil), metas, sel)
 	if len(p.selectedCtxs) == 0 {
 		return ErrClusterNotFound
 	}
@@ -55,11 +114,25 @@ func (p *purger) Purge() error {
 		return errors.Wrapf(ErrMultipleClustersFound, "list: %v", p.contextList())
 	}
 
-	for k, v := range p.selectedCtxs {
-		delete(p.mainKC.Clusters, v.Cluster)
-		delete(p.mainKC.AuthInfos, v.AuthInfo)
+	if _, ok := p.selectedCtxs[p.mainKC.CurrentContext]; ok && !p.opts.AllowCurrent {
+		return errors.Wrapf(ErrPurgeCurrentContext, "context: %v", p.mainKC.CurrentContext)
+	}
+
+	p.metas = metas
+	p.plan = genPurgePlan(p.mainKC, metas, p.state, p.selectedCtxs)
+
+	for _, k := range p.plan.Contexts {
 		delete(p.mainKC.Contexts, k)
 	}
+	for _, k := range p.plan.Clusters {
+		delete(p.mainKC.Clusters, k)
+	}
+	for _, k := range p.plan.Users {
+		delete(p.mainKC.AuthInfos, k)
+	}
+	if p.plan.CurrentContext != "" {
+		p.mainKC.CurrentContext = p.plan.NewCurrentContext
+	}
 
 	return nil
 }
@@ -71,9 +144,29 @@ func (p *purger) contextList() []string {
 		ret = append(ret, k)
 	}
 
+	sort.Strings(ret)
+
 	return ret
 }
 
+// Save writes purged kubeconfig to local store, removes metadata of
+// deleted clusters, and removes cube-owned files no longer used.
+func (p *purger) Save() error {
+	if err := p.store.Save(p.mainKC); err != nil {
+		return err
+	}
+
+	for k := range p.selectedCtxs {
+		delete(p.metas, k)
+	}
+
+	if err := p.metas.SaveTo(p.state.MetaPath()); err != nil {
+		return err
+	}
+
+	return removeFiles(append(p.plan.CertFiles, p.plan.CacheFiles...))
+}
+
 func (p *purger) Result() *clientcmdapi.Config {
 	return p.mainKC
 }
@@ -82,6 +175,195 @@ func (p *purger) Deleted() []string {
 	return p.contextList()
 }
 
+func (p *purger) Plan() PurgePlan {
+	return p.plan
+}
+
+// PurgePlan lists what is affected by purge.
+type PurgePlan struct {
+	Contexts   []string
+	Clusters   []string
+	Users      []string
+	Shared     []string // clusters and users kept as still used by other contexts
+	CertFiles  []string // cube-owned cert files no longer used
+	CacheFiles []string // cube-owned cache files no longer used
+	Tunnels    []string // listening local ports of purged clusters
+
+	CurrentContext    string // set if current-context is purged
+	NewCurrentContext string // current-context after purge, empty means unset
+}
+
+func (p PurgePlan) String() string {
+	var sb strings.Builder
+	write := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "%v:\n", title)
+		for _, v := range items {
+			fmt.Fprintf(&sb, "  - %v\n", v)
+		}
+	}
+
+	write("contexts", p.Contexts)
+	write("clusters", p.Clusters)
+	write("users", p.Users)
+	write("kept (shared)", p.Shared)
+	write("cert files", p.CertFiles)
+	write("cache files", p.CacheFiles)
+	write("tunnels", p.Tunnels)
+	if p.CurrentContext != "" {
+		next := p.NewCurrentContext
+		if next == "" {
+			next = "<unset>"
+		}
+		fmt.Fprintf(&sb, "current-context: %v => %v\n", p.CurrentContext, next)
+	}
+
+	return sb.String()
+}
+
+// genPurgePlan collects entries, files and tunnels affected by purging
+// selected contexts. A cluster, user or file is only deleted when no
+// remaining context uses it.
+func genPurgePlan(kc *clientcmdapi.Config, metas Metas, state base.State, selected map[string]*clientcmdapi.Context) PurgePlan {
+	var plan PurgePlan
+
+	// references from remaining contexts
+	usedClusters := make(map[string]string)
+	usedUsers := make(map[string]string)
+	usedFiles := make(map[string]bool)
+	usedHosts := make(map[string]bool)
+	var remaining []string
+	for k, v := range kc.Contexts {
+		if _, ok := selected[k]; ok {
+			continue
+		}
+
+		remaining = append(remaining, k)
+		usedClusters[v.Cluster] = k
+		usedUsers[v.AuthInfo] = k
+		if c, ok := kc.Clusters[v.Cluster]; ok {
+			usedFiles[c.CertificateAuthority] = true
+		}
+		if u, ok := kc.AuthInfos[v.AuthInfo]; ok {
+			usedFiles[u.ClientCertificate] = true
+			usedFiles[u.ClientKey] = true
+		}
+		if h := remoteHost(kc, metas, k); h != "" {
+			usedHosts[h] = true
+		}
+	}
+
+	clusters := make(map[string]bool)
+	users := make(map[string]bool)
+	shared := make(map[string]bool)
+	files := make(map[string]bool)
+	caches := make(map[string]bool)
+	tunnels := make(map[string]bool)
+
+	for k, v := range selected {
+		plan.Contexts = append(plan.Contexts, k)
+		if k == kc.CurrentContext {
+			plan.CurrentContext = k
+		}
+
+		if h := remoteHost(kc, metas, k); h != "" && !usedHosts[h] {
+			caches[cachePath(state, h)] = true
+		}
+
+		if c, ok := kc.Clusters[v.Cluster]; ok {
+			if owner, used := usedClusters[v.Cluster]; used {
+				shared[fmt.Sprintf("cluster %v, used by %v", v.Cluster, owner)] = true
+			} else {
+				clusters[v.Cluster] = true
+				files[c.CertificateAuthority] = true
+			}
+
+			if h, port, err := GetOccupiedLocalPort(c.Server); err == nil && h == DefaultHost && portListening(port) {
+				tunnels[fmt.Sprintf("127.0.0.1:%d", port)] = true
+			}
+		}
+
+		if u, ok := kc.AuthInfos[v.AuthInfo]; ok {
+			if owner, used := usedUsers[v.AuthInfo]; used {
+				shared[fmt.Sprintf("user %v, used by %v", v.AuthInfo, owner)] = true
+			} else {
+				users[v.AuthInfo] = true
+				files[u.ClientCertificate] = true
+				files[u.ClientKey] = true
+			}
+		}
+	}
+
+	plan.Clusters = sortedKeys(clusters)
+	plan.Users = sortedKeys(users)
+	plan.Shared = sortedKeys(shared)
+	plan.Tunnels = sortedKeys(tunnels)
+	for _, f := range sortedKeys(files) {
+		if !usedFiles[f] && isCubeFile(state.CertDir(), f) {
+			plan.CertFiles = append(plan.CertFiles, f)
+		}
+	}
+	for _, f := range sortedKeys(caches) {
+		if exist, _ := base.FileExists(f); exist {
+			plan.CacheFiles = append(plan.CacheFiles, f)
+		}
+	}
+	sort.Strings(plan.Contexts)
+
+	// re-point current-context if only one context is left, otherwise unset it.
+	if plan.CurrentContext != "" && len(remaining) == 1 {
+		plan.NewCurrentContext = remaining[0]
+	}
+
+	return plan
+}
+
+// remoteHost returns remote host of managed cluster, empty if unknown.
+func remoteHost(kc *clientcmdapi.Config, metas Metas, ctxName string) string {
+	if m := metas.Get(ctxName); m != nil && m.RemoteHost != "" {
+		return m.RemoteHost
+	}
+
+	if m, err := legacyMeta(kc, ctxName); err == nil {
+		return m.RemoteHost
+	}
+
+	return ""
+}
+
+// isCubeFile checks whether the file is under given cube-owned dir.
+func isCubeFile(dir, p string) bool {
+	if p == "" {
+		return false
+	}
+
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// removeFiles removes given files, ignoring missing ones.
+func removeFiles(files []string) error {
+	for _, f := range files {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
 /*
 // inferLocalPort infers local port from default kubeconfig if local-port not provided.
 func (m *merger) inferLocalPort() error {
@@ -0,0 +1,59 @@
+package kube
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+func TestGenPurgePlan(t *testing.T) {
+	listening := portListening
+	portListening = func(port int) bool { return port == 7001 }
+	defer func() { portListening = listening }()
+
+	state := base.NewState("/tmp/state")
+	caPath := filepath.Join(state.CertDir(), "172.31.7.182-ca.crt")
+	newKC := func() *clientcmdapi.Config {
+		kc := newTestConfig("qa", "https://kubernetes:7001")
+		kc.Clusters["qa"].CertificateAuthority = caPath
+		kc.AuthInfos["qa"].ClientCertificate = "/etc/other/client.crt"
+		kc.CurrentContext = "qa"
+		return kc
+	}
+
+	t.Run("single", func(t *testing.T) {
+		assert := assert.New(t)
+
+		kc := newKC()
+		plan := genPurgePlan(kc, Metas{}, state, map[string]*clientcmdapi.Context{"qa": kc.Contexts["qa"]})
+		assert.Equal([]string{"qa"}, plan.Contexts)
+		assert.Equal([]string{"qa"}, plan.Clusters)
+		assert.Equal([]string{"qa"}, plan.Users)
+		assert.Empty(plan.Shared)
+		assert.Equal([]string{caPath}, plan.CertFiles)
+		assert.Equal([]string{"127.0.0.1:7001"}, plan.Tunnels)
+		assert.Equal("qa", plan.CurrentContext)
+		assert.Equal("", plan.NewCurrentContext)
+		assert.Contains(plan.String(), "current-context: qa => <unset>")
+	})
+
+	t.Run("shared", func(t *testing.T) {
+		assert := assert.New(t)
+
+		// another context shares the user, and the cluster uses the same CA file.
+		kc := newKC()
+		kc.Clusters["dev"] = &clientcmdapi.Cluster{Server: "https://kubernetes:7002", CertificateAuthority: caPath}
+		kc.Contexts["dev"] = &clientcmdapi.Context{Cluster: "dev", AuthInfo: "qa"}
+
+		plan := genPurgePlan(kc, Metas{}, state, map[string]*clientcmdapi.Context{"qa": kc.Contexts["qa"]})
+		assert.Equal([]string{"qa"}, plan.Clusters)
+		assert.Empty(plan.Users)
+		assert.Equal([]string{"user qa, used by dev"}, plan.Shared)
+		assert.Empty(plan.CertFiles)
+		assert.Equal("dev", plan.NewCurrentContext)
+	})
+}
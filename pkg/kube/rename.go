@@ -0,0 +1,184 @@
+package kube
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+var (
+	ErrRenameEmptySuffix       = errors.New("cube: empty name suffix for renaming")
+	ErrRenameClusterNotFound   = errors.New("cube: cluster not found for renaming")
+	ErrRenameMultipleClusters  = errors.New("cube: multiple clusters found for renaming")
+	ErrNameSuffixAlreadyExists = errors.New("cube: name suffix already exists")
+	ErrSplitFileAlreadyExists  = errors.New("cube: split kubeconfig file already exists")
+)
+
+// Renamer changes name suffix of a managed cluster in place.
+type Renamer interface {
+	Rename() error
+	Save() error
+	Result() *clientcmdapi.Config
+	Renamed() (oldCtx, newCtx string)
+}
+
+// RenameOptions represents options for rename.
+type RenameOptions struct {
+	OldSuffix string
+	NewSuffix string
+
+	// Naming is templates for local names. If not set, DefaultNaming is used.
+	Naming Naming
+
+	// Store is where kubeconfig is loaded and saved. If not set, NewStore is used.
+	Store *Store
+
+	// StateDir is where metadata is kept. If not set, base.StateDir is used.
+	StateDir string
+}
+
+type renamer struct {
+	opts   RenameOptions
+	store  *Store
+	state  base.State
+	mainKC *clientcmdapi.Config
+	metas  Metas
+
+	oldCtx string
+	newCtx string
+}
+
+func NewRenamer(opts RenameOptions) Renamer {
+	if opts.Naming == (Naming{}) {
+		opts.Naming = DefaultNaming()
+	}
+
+	if opts.Store == nil {
+		opts.Store = NewStore()
+	}
+
+	return &renamer{
+		opts:  opts,
+		store: opts.Store,
+		state: base.NewState(opts.StateDir),
+	}
+}
+
+// Rename rewrites cluster, user and context names of the cluster with old
+// name suffix, following naming templates with the new suffix.
+func (r *renamer) Rename() error {
+	if r.opts.OldSuffix == "" || r.opts.NewSuffix == "" {
+		return ErrRenameEmptySuffix
+	}
+
+	var err error
+	if r.mainKC, err = r.store.Load(); err != nil {
+		return err
+	}
+
+	if r.metas, err = LoadMetasFrom(r.state.MetaPath()); err != nil {
+		return err
+	}
+
+	if r.opts.OldSuffix == r.opts.NewSuffix {
+		return nil
+	}
+
+	if matched := r.findBySuffix(r.opts.NewSuffix); len(matched) > 0 {
+		return errors.Wrapf(ErrNameSuffixAlreadyExists, "suffix: %v, ctx: %v", r.opts.NewSuffix, matched)
+	}
+
+	matched := r.findBySuffix(r.opts.OldSuffix)
+	switch len(matched) {
+	case 0:
+		return errors.Wrapf(ErrRenameClusterNotFound, "suffix: %v", r.opts.OldSuffix)
+	case 1:
+	default:
+		return errors.Wrapf(ErrRenameMultipleClusters, "list: %v", matched)
+	}
+
+	r.oldCtx = matched[0]
+	meta := r.metas.Get(r.oldCtx)
+	if meta == nil {
+		if meta, err = legacyMeta(r.mainKC, r.oldCtx); err != nil {
+			return err
+		}
+	}
+
+	newMeta := *meta
+	newMeta.NameSuffix = r.opts.NewSuffix
+
+	names, err := r.opts.Naming.Render(metaFields(&newMeta))
+	if err != nil {
+		return err
+	}
+
+	if err := r.checkSplitFile(); err != nil {
+		return err
+	}
+
+	if err := renameEntries(r.mainKC, r.oldCtx, names); err != nil {
+		return err
+	}
+
+	r.newCtx = names.Context
+	setLocation(r.mainKC, r.newCtx, r.store.Locate(r.opts.NewSuffix))
+
+	delete(r.metas, r.oldCtx)
+	r.metas[r.newCtx] = &newMeta
+
+	return nil
+}
+
+// findBySuffix returns managed contexts with given name suffix.
+func (r *renamer) findBySuffix(suffix string) []string {
+	var ret []string
+	for k := range r.mainKC.Contexts {
+		meta := r.metas.Get(k)
+		if meta == nil {
+			meta, _ = legacyMeta(r.mainKC, k)
+		}
+
+		if meta != nil && meta.NameSuffix == suffix {
+			ret = append(ret, k)
+		}
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
+
+func (r *renamer) checkSplitFile() error {
+	p := r.store.Locate(r.opts.NewSuffix)
+	if p == "" {
+		return nil
+	}
+
+	if exist, _ := base.FileExists(p); exist {
+		return errors.Wrapf(ErrSplitFileAlreadyExists, "path: %v", p)
+	}
+
+	return nil
+}
+
+// Save writes renamed kubeconfig to local store, and updates metadata.
+func (r *renamer) Save() error {
+	if err := r.store.Save(r.mainKC); err != nil {
+		return err
+	}
+
+	return r.metas.SaveTo(r.state.MetaPath())
+}
+
+func (r *renamer) Result() *clientcmdapi.Config {
+	return r.mainKC
+}
+
+// Renamed returns old and new context names.
+func (r *renamer) Renamed() (string, string) {
+	return r.oldCtx, r.newCtx
+}
@@ -0,0 +1,66 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+func TestRenamer_Rename(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, paths := setupKubeConfigFiles(t)
+	defer os.RemoveAll(dir)
+
+	kc := newTestConfig("kubernetes-qa", "https://kubernetes:7001")
+	ctxName := "kubernetes-admin@172.31.7.182:6443-qa"
+	kc.Contexts[ctxName] = kc.Contexts["kubernetes-qa"]
+	delete(kc.Contexts, "kubernetes-qa")
+	kc.CurrentContext = ctxName
+
+	other := newTestConfig("kubernetes-dev", "https://kubernetes:7002")
+	kc.Clusters["kubernetes-dev"] = other.Clusters["kubernetes-dev"]
+	kc.AuthInfos["kubernetes-dev"] = other.AuthInfos["kubernetes-dev"]
+	kc.Contexts["kubernetes-admin@172.31.7.183:6443-dev"] = other.Contexts["kubernetes-dev"]
+
+	kcPath := filepath.Join(dir, "config")
+	assert.Nil(WriteToFile(kc, kcPath))
+	paths = append(paths, kcPath)
+
+	// kubeconfig and state are given by options, not globals.
+	store := NewStoreWith(StoreOptions{KubeConfig: paths[0]})
+	state := base.NewState(dir)
+
+	metas := Metas{ctxName: &Meta{NameSuffix: "qa", RemoteHost: "172.31.7.182", RemotePort: 6443, LocalPort: 7001}}
+	assert.Nil(metas.SaveTo(state.MetaPath()))
+
+	// collides with existing suffix
+	r := NewRenamer(RenameOptions{OldSuffix: "qa", NewSuffix: "dev", Store: store, StateDir: dir})
+	assert.NotNil(r.Rename())
+
+	r = NewRenamer(RenameOptions{OldSuffix: "qa", NewSuffix: "staging", Store: store, StateDir: dir})
+	assert.Nil(r.Rename())
+	assert.Nil(r.Save())
+
+	oldCtx, newCtx := r.Renamed()
+	assert.Equal(ctxName, oldCtx)
+	assert.Equal("kubernetes-admin@172.31.7.182:6443-staging", newCtx)
+
+	kc, err := Load(kcPath)
+	assert.Nil(err)
+	assert.Equal(newCtx, kc.CurrentContext)
+	assert.Contains(kc.Clusters, "kubernetes-staging")
+	assert.Contains(kc.AuthInfos, "kubernetes-staging")
+	assert.NotContains(kc.Clusters, "kubernetes-qa")
+	assert.Equal("kubernetes-staging", kc.Contexts[newCtx].Cluster)
+
+	metas, err = LoadMetasFrom(state.MetaPath())
+	assert.Nil(err)
+	assert.Nil(metas.Get(ctxName))
+	assert.Equal("staging", metas.Get(newCtx).NameSuffix)
+	assert.Equal(7001, metas.Get(newCtx).LocalPort)
+}
@@ -3,6 +3,7 @@ package kube
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 func GetPortForwardingCmd(localPort int, remoteAPIAddr string, via string) string {
@@ -18,3 +19,16 @@ func GetPortForwardingCmd(localPort int, remoteAPIAddr string, via string) strin
 
 	return fmt.Sprintf(forwardingFmt, localPort, remoteAPIAddr, via)
 }
+
+// GetTunnelCmdArgs returns args of ssh command which runs port forwarding
+// in foreground, so that its lifecycle is owned by caller.
+func GetTunnelCmdArgs(localPort int, remoteAPIAddr string, via string) []string {
+	if via == "" {
+		via = os.Getenv("SSH_VIA")
+	}
+
+	args := []string{"ssh", "-N", "-o", "ExitOnForwardFailure=yes",
+		"-L", fmt.Sprintf("%v:%v", localPort, remoteAPIAddr)}
+
+	return append(args, strings.Fields(via)...)
+}
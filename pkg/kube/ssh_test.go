@@ -32,7 +32,7 @@ func TestGetPortForwardingCmd(t *testing.T) {
 			assert := assert.New(t)
 
 			os.Setenv("SSH_VIA", test.viaEnv)
-			ret := getPortForwardingCmd(localPort, remoteAPIAddr, test.viaEnv)
+			ret := GetPortForwardingCmd(localPort, remoteAPIAddr, test.viaEnv)
 
 			assert.Equal(test.expResult, ret)
 		})
@@ -0,0 +1,145 @@
+package kube
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+var (
+	ErrEmptySSHVia = errors.New("cube: ssh jump server not set")
+)
+
+// SSHForwardHost is the host alias which forwards local ports of all
+// managed clusters through jump server.
+const SSHForwardHost = "cube-forward"
+
+// SSHConfigBlock is the block of ssh config managed by cube.
+var SSHConfigBlock = base.MarkedBlock{
+	Begin: "# BEGIN cube managed block, generated by `cube ssh-config`, do not edit",
+	End:   "# END cube managed block",
+}
+
+// SSHConfigOptions represent options to generate ssh config.
+type SSHConfigOptions struct {
+	Via          string   // jump server, e.g. user@jump
+	RemoteUser   string   // user on masters
+	IdentityFile string   // identity file for masters
+	IPRanges     []string // Host patterns of masters. If empty, derived from managed clusters
+	SocksPort    int      // if set, reach masters via SOCKS proxy on the local port instead of ProxyJump
+	LocalForward bool     // add SSHForwardHost with LocalForward of each managed cluster
+}
+
+// GenSSHConfig generates ssh config entries of jump server and masters of
+// managed clusters, wrapped with markers of SSHConfigBlock.
+func GenSSHConfig(metas Metas, opts SSHConfigOptions) (string, error) {
+	fields := strings.Fields(opts.Via)
+	if len(fields) == 0 {
+		return "", ErrEmptySSHVia
+	}
+
+	via := fields[0]
+	jumpHost, jumpUser := base.ExtractHost(via), base.ExtractUser(via)
+
+	var sb strings.Builder
+	writeHost := func(pattern string, options ...string) {
+		fmt.Fprintf(&sb, "Host %v\n", pattern)
+		for _, v := range options {
+			if v != "" {
+				fmt.Fprintf(&sb, "  %v\n", v)
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	jumpOptions := []string{optionLine("User", jumpUser), "ServerAliveInterval 30"}
+	writeHost(jumpHost, jumpOptions...)
+
+	proxy := "ProxyJump " + via
+	if opts.SocksPort > 0 {
+		proxy = fmt.Sprintf("ProxyCommand /usr/bin/nc -X 4 -x 127.0.0.1:%d %%h %%p", opts.SocksPort)
+	}
+
+	ranges := opts.IPRanges
+	if len(ranges) == 0 {
+		ranges = masterIPRanges(metas)
+	}
+	if len(ranges) > 0 {
+		writeHost(strings.Join(ranges, " "),
+			proxy,
+			optionLine("User", opts.RemoteUser),
+			optionLine("IdentityFile", opts.IdentityFile),
+			"StrictHostKeyChecking no",
+			"UserKnownHostsFile=/dev/null",
+			"LogLevel ERROR",
+		)
+	}
+
+	if opts.LocalForward {
+		forwardOptions := append([]string{"HostName " + jumpHost}, jumpOptions...)
+		forwardOptions = append(forwardOptions, "ExitOnForwardFailure yes")
+		writeHost(SSHForwardHost, append(forwardOptions, localForwards(metas)...)...)
+	}
+
+	return SSHConfigBlock.Wrap(sb.String()), nil
+}
+
+func optionLine(key, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return key + " " + value
+}
+
+// masterIPRanges returns Host patterns covering masters of managed
+// clusters, i.e. `a.b.*` for IPv4 and the host itself for others.
+func masterIPRanges(metas Metas) []string {
+	ranges := make(map[string]bool)
+	for _, m := range metas {
+		if m == nil || m.RemoteHost == "" {
+			continue
+		}
+		ranges[ipRange(m.RemoteHost)] = true
+	}
+
+	ret := make([]string, 0, len(ranges))
+	for k := range ranges {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+func ipRange(host string) string {
+	ip := net.ParseIP(host).To4()
+	if ip == nil {
+		return host
+	}
+
+	return fmt.Sprintf("%d.%d.*", ip[0], ip[1])
+}
+
+// localForwards returns LocalForward lines of managed clusters, ordered by local port.
+func localForwards(metas Metas) []string {
+	var ms []*Meta
+	for _, m := range metas {
+		if m != nil && m.RemoteHost != "" && m.LocalPort > 0 {
+			ms = append(ms, m)
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].LocalPort < ms[j].LocalPort })
+
+	ret := make([]string, 0, len(ms))
+	for _, m := range ms {
+		ret = append(ret, fmt.Sprintf("LocalForward 127.0.0.1:%d %v", m.LocalPort, m.RemoteAPIAddr()))
+	}
+
+	return ret
+}
@@ -0,0 +1,72 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenSSHConfig(t *testing.T) {
+	metas := Metas{
+		"qa":   {NameSuffix: "qa", RemoteHost: "172.31.7.182", RemotePort: 6443, LocalPort: 7002},
+		"dev":  {NameSuffix: "dev", RemoteHost: "172.31.8.10", RemotePort: 6443, LocalPort: 7001},
+		"prod": {NameSuffix: "prod", RemoteHost: "10.0.1.5", RemotePort: 6443, LocalPort: 7003},
+		// nil entry is skipped.
+		"broken": nil,
+	}
+
+	_, err := GenSSHConfig(metas, SSHConfigOptions{})
+	assert.Equal(t, ErrEmptySSHVia, err)
+
+	var tests = []struct {
+		name     string
+		opts     SSHConfigOptions
+		expected string
+	}{
+		{
+			"proxy jump",
+			SSHConfigOptions{Via: "ubuntu@1.2.3.4", RemoteUser: "core"},
+			`Host 1.2.3.4
+  User ubuntu
+  ServerAliveInterval 30
+
+Host 10.0.* 172.31.*
+  ProxyJump ubuntu@1.2.3.4
+  User core
+  StrictHostKeyChecking no
+  UserKnownHostsFile=/dev/null
+  LogLevel ERROR
+`,
+		},
+		{
+			"socks and local forward",
+			SSHConfigOptions{Via: "1.2.3.4", IPRanges: []string{"172.31.*"}, IdentityFile: "~/.ssh/k8s.pem", SocksPort: 62222, LocalForward: true},
+			`Host 1.2.3.4
+  ServerAliveInterval 30
+
+Host 172.31.*
+  ProxyCommand /usr/bin/nc -X 4 -x 127.0.0.1:62222 %h %p
+  IdentityFile ~/.ssh/k8s.pem
+  StrictHostKeyChecking no
+  UserKnownHostsFile=/dev/null
+  LogLevel ERROR
+
+Host cube-forward
+  HostName 1.2.3.4
+  ServerAliveInterval 30
+  ExitOnForwardFailure yes
+  LocalForward 127.0.0.1:7001 172.31.8.10:6443
+  LocalForward 127.0.0.1:7002 172.31.7.182:6443
+  LocalForward 127.0.0.1:7003 10.0.1.5:6443
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, err := GenSSHConfig(metas, tt.opts)
+			assert.Nil(t, err)
+			assert.Equal(t, SSHConfigBlock.Wrap(tt.expected), block)
+		})
+	}
+}
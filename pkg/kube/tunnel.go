@@ -0,0 +1,131 @@
+package kube
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+)
+
+var (
+	ErrTunnelExited  = errors.New("cube: ssh tunnel exited unexpectedly")
+	ErrTunnelTimeout = errors.New("cube: timeout waiting for ssh tunnel")
+)
+
+// interval to check whether tunnel is up
+const tunnelPollInterval = 100 * time.Millisecond
+
+// Tunnel is ssh port forwarding started and owned by cube.
+type Tunnel struct {
+	LocalPort int
+
+	cmd  *exec.Cmd
+	done chan error
+}
+
+// StartTunnel starts ssh port forwarding in background, and waits until
+// local port is listening. Output of ssh is written to stderr, discarded
+// if nil.
+func StartTunnel(localPort int, remoteAPIAddr, via string, timeout time.Duration, stderr io.Writer) (*Tunnel, error) {
+	args := GetTunnelCmdArgs(localPort, remoteAPIAddr, via)
+
+	if stderr == nil {
+		stderr = ioutil.Discard
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	t := &Tunnel{LocalPort: localPort, cmd: cmd, done: make(chan error, 1)}
+	go func() { t.done <- cmd.Wait() }()
+
+	deadline := time.Now().Add(timeout)
+	for !base.IsListening(localPort) {
+		select {
+		case err := <-t.done:
+			return nil, errors.Wrapf(ErrTunnelExited, "port: %v, error: %v", localPort, err)
+		case <-time.After(tunnelPollInterval):
+		}
+
+		if time.Now().After(deadline) {
+			_ = t.Stop()
+			return nil, errors.Wrapf(ErrTunnelTimeout, "port: %v, timeout: %v", localPort, timeout)
+		}
+	}
+
+	return t, nil
+}
+
+// Stop terminates the tunnel, no-op if it already exited.
+func (t *Tunnel) Stop() error {
+	select {
+	case <-t.done:
+		return nil
+	default:
+	}
+
+	if err := t.cmd.Process.Kill(); err != nil {
+		return err
+	}
+	<-t.done
+
+	return nil
+}
+
+// freeLocalPort returns a port on localhost which is free right now.
+func freeLocalPort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+// MinimalConfig returns a self-contained kubeconfig holding only given
+// context and its cluster and user, with cert files inlined.
+func MinimalConfig(kc *clientcmdapi.Config, ctxName string) (*clientcmdapi.Config, error) {
+	if _, ok := kc.Contexts[ctxName]; !ok {
+		return nil, errors.Wrapf(ErrContextNotFound, "ctx: %v", ctxName)
+	}
+
+	ret := kc.DeepCopy()
+	ret.CurrentContext = ctxName
+	if err := clientcmdapi.MinifyConfig(ret); err != nil {
+		return nil, err
+	}
+
+	if err := clientcmdapi.FlattenConfig(ret); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// WriteTempConfig writes kubeconfig to a temp file readable only by
+// current user, and returns its path.
+func WriteTempConfig(kc *clientcmdapi.Config) (string, error) {
+	f, err := ioutil.TempFile("", "cube-kubeconfig-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+
+	if err := WriteToFile(kc, f.Name()); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
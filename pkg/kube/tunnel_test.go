@@ -0,0 +1,57 @@
+package kube
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinimalConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "cube-tunnel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	caPath := filepath.Join(dir, "ca.crt")
+	assert.Nil(ioutil.WriteFile(caPath, []byte("ca-data"), 0600))
+
+	kc := newTestConfig("qa", "https://kubernetes:7001")
+	kc.Clusters["qa"].CertificateAuthority = caPath
+	other := newTestConfig("prod", "https://kubernetes:7002")
+	kc.Clusters["prod"] = other.Clusters["prod"]
+	kc.AuthInfos["prod"] = other.AuthInfos["prod"]
+	kc.Contexts["prod"] = other.Contexts["prod"]
+	kc.CurrentContext = "prod"
+
+	minKC, err := MinimalConfig(kc, "qa")
+	assert.Nil(err)
+	assert.Equal("qa", minKC.CurrentContext)
+	assert.Len(minKC.Contexts, 1)
+	assert.Len(minKC.Clusters, 1)
+	assert.Len(minKC.AuthInfos, 1)
+	assert.Equal([]byte("ca-data"), minKC.Clusters["qa"].CertificateAuthorityData)
+
+	// original config is untouched.
+	assert.Equal("prod", kc.CurrentContext)
+	assert.Len(kc.Contexts, 2)
+
+	p, err := WriteTempConfig(minKC)
+	assert.Nil(err)
+	defer os.Remove(p)
+
+	info, err := os.Stat(p)
+	assert.Nil(err)
+	assert.Equal(os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestGetTunnelCmdArgs(t *testing.T) {
+	args := GetTunnelCmdArgs(7001, "172.17.31.1:6443", "-J jump core@192.168.1.1")
+	assert.Equal(t, []string{"ssh", "-N", "-o", "ExitOnForwardFailure=yes",
+		"-L", "7001:172.17.31.1:6443", "-J", "jump", "core@192.168.1.1"}, args)
+}
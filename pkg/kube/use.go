@@ -0,0 +1,108 @@
+package kube
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+var (
+	ErrContextNotFound       = errors.New("cube: context not found")
+	ErrMultipleContextsFound = errors.New("cube: multiple contexts found")
+	ErrNoPreviousContext     = errors.New("cube: no previous context")
+	ErrNoCurrentContext      = errors.New("cube: current-context not set")
+)
+
+// ResolveContext returns the only context matching m.
+func ResolveContext(kc *clientcmdapi.Config, metas Metas, m matcher.Matcher) (string, error) {
+	ctxs := FindContexts(kc, metas, m, nil)
+
+	switch len(ctxs) {
+	case 0:
+		return "", ErrContextNotFound
+	case 1:
+		for k := range ctxs {
+			return k, nil
+		}
+	}
+
+	names := make([]string, 0, len(ctxs))
+	for k := range ctxs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	return "", errors.Wrapf(ErrMultipleContextsFound, "list: %v", names)
+}
+
+// SwitchContext sets current-context, and records the previous one in
+// state for switching back. It returns the previous context.
+func SwitchContext(store *Store, state base.State, kc *clientcmdapi.Config, ctxName string) (string, error) {
+	if _, ok := kc.Contexts[ctxName]; !ok {
+		return "", errors.Wrapf(ErrContextNotFound, "ctx: %v", ctxName)
+	}
+
+	prev := kc.CurrentContext
+	if prev == ctxName {
+		return prev, nil
+	}
+
+	kc.CurrentContext = ctxName
+	if err := store.Save(kc); err != nil {
+		return "", err
+	}
+
+	if prev == "" {
+		return prev, nil
+	}
+
+	return prev, savePreviousContext(state, prev)
+}
+
+// PreviousContext returns the context used before last switch, recorded
+// in state.
+func PreviousContext(state base.State) (string, error) {
+	content, err := ioutil.ReadFile(state.PrevCtxPath())
+	if os.IsNotExist(err) {
+		return "", ErrNoPreviousContext
+	}
+	if err != nil {
+		return "", err
+	}
+
+	ctxName := strings.TrimSpace(string(content))
+	if ctxName == "" {
+		return "", ErrNoPreviousContext
+	}
+
+	return ctxName, nil
+}
+
+func savePreviousContext(state base.State, ctxName string) error {
+	p := state.PrevCtxPath()
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p, []byte(ctxName+"\n"), 0644)
+}
+
+// SetNamespace sets namespace of current-context.
+func SetNamespace(store *Store, kc *clientcmdapi.Config, ns string) (string, error) {
+	ctx, ok := kc.Contexts[kc.CurrentContext]
+	if kc.CurrentContext == "" || !ok {
+		return "", ErrNoCurrentContext
+	}
+
+	ctx.Namespace = ns
+
+	return kc.CurrentContext, store.Save(kc)
+}
@@ -0,0 +1,65 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/matcher"
+)
+
+func TestSwitchContext(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, paths := setupKubeConfigFiles(t, "a", "b")
+	defer os.RemoveAll(dir)
+
+	os.Setenv(base.KubeConfigEnv, paths[0]+string(filepath.ListSeparator)+paths[1])
+	defer os.Unsetenv(base.KubeConfigEnv)
+
+	state := base.NewState(dir)
+
+	_, err := PreviousContext(state)
+	assert.True(errors.Is(err, ErrNoPreviousContext))
+
+	store := NewStore()
+	kc, err := store.Load()
+	assert.Nil(err)
+
+	m, _ := matcher.New(matcher.Exact, "b")
+	ctxName, err := ResolveContext(kc, nil, m)
+	assert.Nil(err)
+	assert.Equal("b", ctxName)
+
+	m, _ = matcher.New(matcher.Exact, "c")
+	_, err = ResolveContext(kc, nil, m)
+	assert.True(errors.Is(err, ErrContextNotFound))
+
+	// switch a => b, then back to a.
+	kc.CurrentContext = "a"
+	assert.Nil(store.Save(kc))
+
+	prev, err := SwitchContext(store, state, kc, "b")
+	assert.Nil(err)
+	assert.Equal("a", prev)
+
+	kc, err = store.Load()
+	assert.Nil(err)
+	assert.Equal("b", kc.CurrentContext)
+
+	prevCtx, err := PreviousContext(state)
+	assert.Nil(err)
+	assert.Equal("a", prevCtx)
+
+	ctxName, err = SetNamespace(store, kc, "kube-system")
+	assert.Nil(err)
+	assert.Equal("b", ctxName)
+
+	second, err := Load(paths[1])
+	assert.Nil(err)
+	assert.Equal("kube-system", second.Contexts["b"].Namespace)
+}
@@ -1,3 +1,210 @@
+// Package log is a leveled, structured logger for diagnostics of cube.
+// It writes to stderr by default, so that stdout only holds command output.
 package log
 
-// TODO
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is severity of log entry.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// Format is how log entry is encoded.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat parses log format, text or json.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatText, FormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid log format - %v", s)
+	}
+}
+
+// Logger writes entries at or above its level. Entry is made of a message
+// and key/value pairs, e.g. `Warn("cert expired", "context", "qa")`.
+type Logger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	level  Level
+	format Format
+
+	now func() time.Time
+}
+
+// New creates a logger.
+func New(out io.Writer, level Level, format Format) *Logger {
+	return &Logger{out: out, level: level, format: format, now: time.Now}
+}
+
+// SetOutput sets destination of logger.
+func (l *Logger) SetOutput(out io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out = out
+}
+
+// Writer returns destination of logger.
+func (l *Logger) Writer() io.Writer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.out
+}
+
+// SetLevel sets minimal level of entries written.
+func (l *Logger) SetLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+// SetFormat sets encoding of entries.
+func (l *Logger) SetFormat(format Format) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.format = format
+}
+
+// Enabled checks whether entries of given level are written.
+func (l *Logger) Enabled(level Level) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return level >= l.level
+}
+
+func (l *Logger) Debug(msg string, kv ...interface{}) { l.log(LevelDebug, msg, kv) }
+func (l *Logger) Info(msg string, kv ...interface{})  { l.log(LevelInfo, msg, kv) }
+func (l *Logger) Warn(msg string, kv ...interface{})  { l.log(LevelWarn, msg, kv) }
+func (l *Logger) Error(msg string, kv ...interface{}) { l.log(LevelError, msg, kv) }
+
+func (l *Logger) log(level Level, msg string, kv []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if level < l.level {
+		return
+	}
+
+	var line string
+	if l.format == FormatJSON {
+		line = l.encodeJSON(level, msg, kv)
+	} else {
+		line = encodeText(level, msg, kv)
+	}
+
+	io.WriteString(l.out, line+"\n")
+}
+
+// encodeText encodes entry as `[LEVEL] msg key=value ...`.
+func encodeText(level Level, msg string, kv []interface{}) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%v] %v", level, msg)
+
+	for i := 0; i < len(kv); i += 2 {
+		k, v := pair(kv, i)
+		fmt.Fprintf(&sb, " %v=%v", k, quote(fmt.Sprint(v)))
+	}
+
+	return sb.String()
+}
+
+func (l *Logger) encodeJSON(level Level, msg string, kv []interface{}) string {
+	var sb strings.Builder
+	sb.WriteString("{")
+	writeField := func(k string, v interface{}) {
+		if sb.Len() > 1 {
+			sb.WriteString(",")
+		}
+
+		key, _ := json.Marshal(k)
+		val, err := json.Marshal(v)
+		if err != nil {
+			val, _ = json.Marshal(fmt.Sprint(v))
+		}
+		sb.Write(key)
+		sb.WriteString(":")
+		sb.Write(val)
+	}
+
+	writeField("time", l.now().Format(time.RFC3339))
+	writeField("level", strings.ToLower(level.String()))
+	writeField("msg", msg)
+	for i := 0; i < len(kv); i += 2 {
+		k, v := pair(kv, i)
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		writeField(k, v)
+	}
+	sb.WriteString("}")
+
+	return sb.String()
+}
+
+// pair returns i-th key/value, key without value is kept with value `<missing>`.
+func pair(kv []interface{}, i int) (string, interface{}) {
+	k := fmt.Sprint(kv[i])
+	if i+1 >= len(kv) {
+		return k, "<missing>"
+	}
+
+	return k, kv[i+1]
+}
+
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+// std is the logger used by package level functions.
+var std = New(os.Stderr, LevelInfo, FormatText)
+
+// Default returns the logger used by package level functions.
+func Default() *Logger { return std }
+
+func SetOutput(out io.Writer)  { std.SetOutput(out) }
+func Writer() io.Writer        { return std.Writer() }
+func SetLevel(level Level)     { std.SetLevel(level) }
+func SetFormat(format Format)  { std.SetFormat(format) }
+func Enabled(level Level) bool { return std.Enabled(level) }
+
+func Debug(msg string, kv ...interface{}) { std.log(LevelDebug, msg, kv) }
+func Info(msg string, kv ...interface{})  { std.log(LevelInfo, msg, kv) }
+func Warn(msg string, kv ...interface{})  { std.log(LevelWarn, msg, kv) }
+func Error(msg string, kv ...interface{}) { std.log(LevelError, msg, kv) }
@@ -0,0 +1,58 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Text(t *testing.T) {
+	assert := assert.New(t)
+
+	var out bytes.Buffer
+	l := New(&out, LevelInfo, FormatText)
+
+	l.Debug("hidden")
+	l.Info("switched", "context", "qa")
+	l.Warn("cert expired", "context", "kubernetes-admin@172.31.7.182:6443-qa", "reason", "not after 2020-01-01")
+	l.Error("failed", "err", errors.New("boom"), "dangling")
+
+	assert.Equal("[INFO] switched context=qa\n"+
+		"[WARN] cert expired context=kubernetes-admin@172.31.7.182:6443-qa reason=\"not after 2020-01-01\"\n"+
+		"[ERROR] failed err=boom dangling=<missing>\n", out.String())
+}
+
+func TestLogger_JSON(t *testing.T) {
+	var out bytes.Buffer
+	l := New(&out, LevelDebug, FormatJSON)
+	l.now = func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	l.Debug("tunnel started", "port", 7001, "err", errors.New("none"))
+
+	assert.Equal(t, `{"time":"2020-01-02T03:04:05Z","level":"debug","msg":"tunnel started","port":7001,"err":"none"}`+"\n", out.String())
+}
+
+func TestLogger_Level(t *testing.T) {
+	assert := assert.New(t)
+
+	var out bytes.Buffer
+	l := New(&out, LevelError, FormatText)
+	l.Warn("hidden")
+	assert.Empty(out.String())
+	assert.False(l.Enabled(LevelWarn))
+
+	l.SetLevel(LevelDebug)
+	assert.True(l.Enabled(LevelDebug))
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("JSON")
+	assert.Nil(t, err)
+	assert.Equal(t, FormatJSON, f)
+
+	_, err = ParseFormat("xml")
+	assert.NotNil(t, err)
+}
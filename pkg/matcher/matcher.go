@@ -0,0 +1,135 @@
+// Package matcher selects clusters by name. All cube commands which take
+// a cluster name share the same matching modes.
+package matcher
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Mode is how a name pattern is matched.
+type Mode string
+
+const (
+	Exact  Mode = "exact"
+	Prefix Mode = "prefix"
+	Glob   Mode = "glob"
+	Regex  Mode = "regex"
+)
+
+const (
+	// env var to override default exclusion pattern, empty means no exclusion.
+	envExclude = "CUBE_EXCLUDE"
+
+	// defaultExclude excludes local clusters from operations like forwarding.
+	defaultExclude = "kind|minikube"
+)
+
+var (
+	ErrInvalidMode    = errors.New("cube: invalid match mode, must be one of exact|prefix|glob|regex")
+	ErrInvalidPattern = errors.New("cube: invalid name pattern")
+)
+
+// Matcher matches names against a pattern.
+type Matcher interface {
+	Match(name string) bool
+}
+
+// MatchFunc adapts a function to Matcher.
+type MatchFunc func(name string) bool
+
+func (f MatchFunc) Match(name string) bool {
+	return f(name)
+}
+
+// All matches any name.
+var All = MatchFunc(func(string) bool { return true })
+
+// None matches no name.
+var None = MatchFunc(func(string) bool { return false })
+
+// ParseMode parses match mode.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case Exact, Prefix, Glob, Regex:
+		return m, nil
+	default:
+		return "", errors.Wrapf(ErrInvalidMode, "mode: %v", s)
+	}
+}
+
+// New creates a matcher for the pattern in given mode. Empty pattern
+// matches all names. Regex is unanchored, i.e. `qa` matches `preqa`.
+func New(mode Mode, pattern string) (Matcher, error) {
+	if pattern == "" {
+		return All, nil
+	}
+
+	switch mode {
+	case Exact:
+		return MatchFunc(func(name string) bool { return name == pattern }), nil
+	case Prefix:
+		return MatchFunc(func(name string) bool { return strings.HasPrefix(name, pattern) }), nil
+	case Glob:
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.Wrapf(ErrInvalidPattern, "glob: %v, error: %v", pattern, err)
+		}
+		return MatchFunc(func(name string) bool {
+			ok, _ := path.Match(pattern, name)
+			return ok
+		}), nil
+	case Regex:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidPattern, "regex: %v, error: %v", pattern, err)
+		}
+		return MatchFunc(re.MatchString), nil
+	default:
+		return nil, errors.Wrapf(ErrInvalidMode, "mode: %v", mode)
+	}
+}
+
+// Parse creates a matcher from mode string and pattern.
+func Parse(mode, pattern string) (Matcher, error) {
+	m, err := ParseMode(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(m, pattern)
+}
+
+// Any returns true if any of the names matches.
+func Any(m Matcher, names ...string) bool {
+	for _, n := range names {
+		if m.Match(n) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DefaultExclude returns regex of names excluded by default, which can be
+// overridden by `CUBE_EXCLUDE` env.
+func DefaultExclude() string {
+	if v, ok := os.LookupEnv(envExclude); ok {
+		return v
+	}
+
+	return defaultExclude
+}
+
+// NewExclude creates a regex matcher for excluded names. Empty pattern
+// excludes nothing.
+func NewExclude(pattern string) (Matcher, error) {
+	if pattern == "" {
+		return None, nil
+	}
+
+	return New(Regex, pattern)
+}
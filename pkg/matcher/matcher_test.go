@@ -0,0 +1,69 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher(t *testing.T) {
+	names := []string{"qa", "qa2", "preqa", "prod-eu", "kubernetes-admin@172.31.7.182:6443-qa"}
+
+	tests := []struct {
+		mode     Mode
+		pattern  string
+		expected []string
+	}{
+		{Exact, "qa", []string{"qa"}},
+		{Prefix, "qa", []string{"qa", "qa2"}},
+		{Glob, "*-qa", []string{"kubernetes-admin@172.31.7.182:6443-qa"}},
+		{Glob, "prod-??", []string{"prod-eu"}},
+		{Regex, "qa$", []string{"qa", "preqa", "kubernetes-admin@172.31.7.182:6443-qa"}},
+		{Exact, "", names},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.mode)+"/"+test.pattern, func(t *testing.T) {
+			assert := assert.New(t)
+
+			m, err := New(test.mode, test.pattern)
+			assert.Nil(err)
+
+			var matched []string
+			for _, n := range names {
+				if m.Match(n) {
+					matched = append(matched, n)
+				}
+			}
+			assert.Equal(test.expected, matched)
+		})
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Parse("contains", "qa")
+	assert.True(errors.Is(err, ErrInvalidMode))
+
+	_, err = Parse("regex", "qa(")
+	assert.True(errors.Is(err, ErrInvalidPattern))
+
+	_, err = Parse("glob", "[qa")
+	assert.True(errors.Is(err, ErrInvalidPattern))
+}
+
+func TestNewExclude(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewExclude(defaultExclude)
+	assert.Nil(err)
+	assert.True(m.Match("kind-dev"))
+	assert.True(m.Match("minikube"))
+	assert.False(m.Match("qa"))
+
+	m, err = NewExclude("")
+	assert.Nil(err)
+	assert.False(m.Match("kind-dev"))
+}
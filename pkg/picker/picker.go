@@ -0,0 +1,97 @@
+// Package picker provides an interactive fuzzy picker for clusters.
+package picker
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/manifoldco/promptui"
+
+	"github.com/shohi/cube/pkg/base"
+	"github.com/shohi/cube/pkg/kube"
+)
+
+var (
+	ErrNotInteractive = errors.New("cube: picker requires a terminal")
+	ErrNoCluster      = errors.New("cube: no cluster to pick")
+)
+
+// page size of picker list
+const pickerSize = 15
+
+var templates = &promptui.SelectTemplates{
+	Label:    "{{ . }}",
+	Active:   "▸ {{ .Name | cyan }} {{ .Context | faint }}",
+	Inactive: "  {{ .Name }} {{ .Context | faint }}",
+	Selected: "✔ {{ .Context }}",
+	Details: `
+--------- cluster ----------
+{{ "Context:" | faint }}	{{ .Context }}
+{{ "Type:" | faint }}	{{ .Type }}
+{{ "Remote API:" | faint }}	{{ .RemoteAPIAddr }}
+{{ "Local Port:" | faint }}	{{ .LocalPort }}
+{{ "Tunnel:" | faint }}	{{ .Tunnel }}
+{{ "Cert Expiry:" | faint }}	{{ .CertExpiry }}`,
+}
+
+// Available checks whether picker can be used, i.e. both stdin and stdout
+// are terminals.
+func Available() bool {
+	return base.IsTerminal(os.Stdin) && base.IsTerminal(os.Stdout)
+}
+
+// Pick shows clusters in a fuzzy picker, and returns the selected one.
+func Pick(label string, infos kube.ClusterInfos) (kube.ClusterInfo, error) {
+	if !Available() {
+		return kube.ClusterInfo{}, ErrNotInteractive
+	}
+
+	if len(infos) == 0 {
+		return kube.ClusterInfo{}, ErrNoCluster
+	}
+
+	s := promptui.Select{
+		Label:             label,
+		Items:             infos,
+		Templates:         templates,
+		Size:              pickerSize,
+		StartInSearchMode: true,
+		Searcher: func(input string, index int) bool {
+			c := infos[index]
+			return FuzzyMatch(input, c.Name+" "+c.Context)
+		},
+	}
+
+	idx, _, err := s.Run()
+	if err != nil {
+		return kube.ClusterInfo{}, err
+	}
+
+	return infos[idx], nil
+}
+
+// FuzzyMatch checks whether all characters of pattern appear in s in
+// order, case-insensitively and ignoring spaces in pattern, e.g. `pq`
+// matches `prod-qa`.
+func FuzzyMatch(pattern, s string) bool {
+	target := []rune(strings.ToLower(s))
+
+	i := 0
+	for _, r := range strings.ToLower(pattern) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+
+		for i < len(target) && target[i] != r {
+			i++
+		}
+		if i == len(target) {
+			return false
+		}
+		i++
+	}
+
+	return true
+}
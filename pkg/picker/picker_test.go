@@ -0,0 +1,28 @@
+package picker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		s        string
+		expected bool
+	}{
+		{"", "prod-qa", true},
+		{"pq", "prod-qa", true},
+		{"PQ", "prod-qa", true},
+		{"182 qa", "172.31.7.182-qa", true},
+		{"qp", "prod-qa", false},
+		{"prodx", "prod-qa", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			assert.Equal(t, test.expected, FuzzyMatch(test.pattern, test.s))
+		})
+	}
+}
@@ -3,7 +3,10 @@ package scp
 import (
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 )
 
 type TransferDirect int
@@ -19,6 +22,10 @@ type TransferConfig struct {
 	RemotePath string
 
 	LocalPath string
+
+	// Stdout and Stderr receive output of scp, discarded if nil.
+	Stdout io.Writer
+	Stderr io.Writer
 }
 
 var (
@@ -60,6 +67,9 @@ func TransferFile(conf TransferConfig) error {
 
 	switch conf.Direct {
 	case ToRemote:
+		if err := os.MkdirAll(filepath.Dir(conf.LocalPath), os.ModePerm); err != nil {
+			return err
+		}
 		args = []string{remoteLoc, conf.LocalPath}
 	default:
 		args = []string{conf.LocalPath, remoteLoc}
@@ -67,6 +77,7 @@ func TransferFile(conf TransferConfig) error {
 
 	// TODO: add timeout control
 	cmd := exec.Command("scp", args...)
+	cmd.Stdout, cmd.Stderr = conf.Stdout, conf.Stderr
 
 	// TODO: dump log
 	if err := cmd.Run(); err != nil {